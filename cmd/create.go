@@ -3,22 +3,139 @@ package cmd
 import (
 	"fmt"
 
-	"github.com/bait-py/autostack/internal/stack"
+	"github.com/bait-py/autostack/pkg/autostack"
 
 	"github.com/spf13/cobra"
 )
 
+var createOpts struct {
+	set     []string
+	ports   []string
+	values  string
+	yes     bool
+	noStart bool
+	name    string
+	dir     string
+	force   bool
+	merge   bool
+	dryRun  bool
+
+	autoPorts       bool
+	allowPrivileged bool
+	inlineEnv       bool
+	secrets         string
+}
+
 var createCmd = &cobra.Command{
 	Use:   "create [stack]",
 	Short: "Create a stack",
-	Args:  cobra.ExactArgs(1),
+	Example: `  autostack create lamp
+  autostack create lamp --set MYSQL_USER=app --port web=9000 --yes --no-start
+  autostack create mariadb --values values.yaml --yes
+  autostack create lamp --name shop --dir projects/shop
+  autostack create lamp --yes --dry-run
+  autostack create mariadb --secrets=files`,
+	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		// Arguments are valid from here on, errors are not usage problems
+		cmd.SilenceUsage = true
+
+		opts, err := buildCreateOptions()
+		if err != nil {
+			return err
+		}
+		// The runner is only detected when the stack is started
+		opts.Compose = composeName
+
+		opts.UI = newUI(cmd)
+
 		stackName := args[0]
-		fmt.Printf("Creating stack: %s\n", stackName)
-		return stack.Create(stackName)
+		opts.UI.Printf("Creating stack: %s\n", stackName)
+		return autostack.Create(cmd.Context(), stackName, opts)
 	},
 }
 
+// buildCreateOptions merges the values file with --set and --port flags,
+// which take precedence
+func buildCreateOptions() (autostack.CreateOptions, error) {
+	opts := autostack.CreateOptions{
+		Env:     make(map[string]string),
+		Ports:   make(map[string]string),
+		Yes:     createOpts.yes,
+		NoStart: createOpts.noStart,
+		Name:    createOpts.name,
+		Dir:     createOpts.dir,
+		DryRun:  createOpts.dryRun,
+
+		AutoPorts:       createOpts.autoPorts,
+		AllowPrivileged: createOpts.allowPrivileged,
+		InlineEnv:       createOpts.inlineEnv,
+	}
+
+	switch createOpts.secrets {
+	case autostack.SecretsEnv:
+	case autostack.SecretsFiles:
+		opts.SecretFiles = true
+	default:
+		return opts, fmt.Errorf("invalid --secrets mode %q: use %s or %s", createOpts.secrets, autostack.SecretsEnv, autostack.SecretsFiles)
+	}
+
+	switch {
+	case createOpts.force:
+		opts.Existing = autostack.OverwriteExisting
+	case createOpts.merge:
+		opts.Existing = autostack.MergeExisting
+	}
+
+	if createOpts.values != "" {
+		env, ports, err := autostack.LoadValuesFile(createOpts.values)
+		if err != nil {
+			return opts, err
+		}
+		for key, value := range env {
+			opts.Env[key] = value
+		}
+		for service, port := range ports {
+			opts.Ports[service] = port
+		}
+	}
+
+	env, err := autostack.ParseAssignments(createOpts.set)
+	if err != nil {
+		return opts, err
+	}
+	for key, value := range env {
+		opts.Env[key] = value
+	}
+
+	ports, err := autostack.ParseAssignments(createOpts.ports)
+	if err != nil {
+		return opts, err
+	}
+	for service, port := range ports {
+		opts.Ports[service] = port
+	}
+
+	return opts, nil
+}
+
 func init() {
+	flags := createCmd.Flags()
+	flags.StringArrayVar(&createOpts.set, "set", nil, "set an environment variable (KEY=VALUE, repeatable)")
+	flags.StringArrayVar(&createOpts.ports, "port", nil, "set a service port (SERVICE=PORT, repeatable)")
+	flags.StringVarP(&createOpts.values, "values", "f", "", "read environment variables and ports from a YAML file")
+	flags.BoolVarP(&createOpts.yes, "yes", "y", false, "use defaults for unset values and skip every prompt")
+	flags.BoolVar(&createOpts.noStart, "no-start", false, "do not start the stack after creation")
+	flags.StringVar(&createOpts.name, "name", "", "project name used for the compose project and container names (default: the name of --dir or the stack name)")
+	flags.StringVar(&createOpts.dir, "dir", "", "output directory (default: the project name or the stack default)")
+	flags.BoolVar(&createOpts.force, "force", false, "overwrite files that already exist")
+	flags.BoolVar(&createOpts.merge, "merge", false, "keep existing files and only add missing ones")
+	flags.BoolVar(&createOpts.dryRun, "dry-run", false, "show the generated files, or a diff against the existing directory, without writing anything")
+	flags.BoolVar(&createOpts.autoPorts, "auto-ports", false, "pick the next free port when a port is taken")
+	flags.BoolVar(&createOpts.allowPrivileged, "allow-privileged-ports", false, "accept host ports below 1024")
+	flags.BoolVar(&createOpts.inlineEnv, "inline-env", false, "write values into docker-compose.yml instead of a .env file")
+	flags.StringVar(&createOpts.secrets, "secrets", autostack.SecretsEnv, "where secrets are stored: env, or files mounted as Docker secrets")
+	createCmd.MarkFlagsMutuallyExclusive("force", "merge")
+
 	rootCmd.AddCommand(createCmd)
 }
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"github.com/bait-py/autostack/pkg/autostack"
+
+	"github.com/spf13/cobra"
+)
+
+var lifecycleOpts struct {
+	services []string
+	follow   bool
+	tail     string
+	volumes  bool
+}
+
+var upCmd = &cobra.Command{
+	Use:   "up [project]",
+	Short: "Start a generated stack in the background",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCompose(cmd, args, append([]string{"up", "-d"}, lifecycleOpts.services...))
+	},
+}
+
+var downCmd = &cobra.Command{
+	Use:   "down [project]",
+	Short: "Stop and remove the containers of a generated stack",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		composeArgs := []string{"down"}
+		if lifecycleOpts.volumes {
+			composeArgs = append(composeArgs, "--volumes")
+		}
+		return runCompose(cmd, args, composeArgs)
+	},
+}
+
+var restartCmd = &cobra.Command{
+	Use:   "restart [project]",
+	Short: "Restart the services of a generated stack",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCompose(cmd, args, append([]string{"restart"}, lifecycleOpts.services...))
+	},
+}
+
+var logsCmd = &cobra.Command{
+	Use:   "logs [project]",
+	Short: "Show the logs of a generated stack",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		composeArgs := []string{"logs"}
+		if lifecycleOpts.follow {
+			composeArgs = append(composeArgs, "--follow")
+		}
+		if lifecycleOpts.tail != "" {
+			composeArgs = append(composeArgs, "--tail", lifecycleOpts.tail)
+		}
+		return runCompose(cmd, args, append(composeArgs, lifecycleOpts.services...))
+	},
+}
+
+var psCmd = &cobra.Command{
+	Use:   "ps [project]",
+	Short: "List the containers of a generated stack",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCompose(cmd, args, []string{"ps"})
+	},
+}
+
+var execCmd = &cobra.Command{
+	Use:   "exec <project> <service> [command...]",
+	Short: "Run a command in a running service of a generated stack",
+	Example: `  autostack exec lamp-stack web
+  autostack exec shop db -- mysql -u root -p`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		command := args[2:]
+		if len(command) == 0 {
+			command = []string{"sh"}
+		}
+		return runCompose(cmd, args[:1], append([]string{"exec", args[1]}, command...))
+	},
+}
+
+// runCompose runs compose in the project named by args, the current
+// directory when none is given
+func runCompose(cmd *cobra.Command, args []string, composeArgs []string) error {
+	cmd.SilenceUsage = true
+
+	var ref string
+	if len(args) > 0 {
+		ref = args[0]
+	}
+	dir, err := autostack.FindProjectDir(ref)
+	if err != nil {
+		return err
+	}
+
+	runner, err := composeRunner(cmd)
+	if err != nil {
+		return err
+	}
+	return runner.Run(cmd.Context(), dir, composeArgs...)
+}
+
+func init() {
+	for _, c := range []*cobra.Command{upCmd, restartCmd, logsCmd} {
+		c.Flags().StringArrayVarP(&lifecycleOpts.services, "service", "s", nil, "limit to a service (repeatable)")
+	}
+	logsCmd.Flags().BoolVarP(&lifecycleOpts.follow, "follow", "f", false, "follow log output")
+	logsCmd.Flags().StringVar(&lifecycleOpts.tail, "tail", "", "number of lines to show from the end of the logs")
+	downCmd.Flags().BoolVarP(&lifecycleOpts.volumes, "volumes", "v", false, "also remove named and anonymous volumes")
+
+	rootCmd.AddCommand(upCmd, downCmd, restartCmd, logsCmd, psCmd, execCmd)
+}
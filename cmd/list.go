@@ -1,7 +1,11 @@
 package cmd
 
 import (
-	"github.com/bait-py/autostack/internal/stack"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bait-py/autostack/pkg/autostack"
 	"github.com/spf13/cobra"
 )
 
@@ -9,7 +13,21 @@ var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List all available stacks",
 	Run: func(cmd *cobra.Command, args []string) {
-		stack.ListStacks()
+		out := cmd.OutOrStdout()
+		fmt.Fprintln(out, "\nAvailable stacks:")
+
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  NAME\tSOURCE\tDESCRIPTION")
+		for _, s := range autostack.Stacks() {
+			name := s.Name
+			if len(s.Aliases) > 0 {
+				name = fmt.Sprintf("%s (%s)", s.Name, strings.Join(s.Aliases, ", "))
+			}
+			fmt.Fprintf(w, "  %s\t%s\t%s\n", name, s.Source, s.Description)
+		}
+		w.Flush()
+
+		fmt.Fprintln(out)
 	},
 }
 
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bait-py/autostack/pkg/autostack"
+
+	"github.com/spf13/cobra"
+)
+
+var projectsCmd = &cobra.Command{
+	Use:     "projects",
+	Aliases: []string{"project"},
+	Short:   "List the projects generated on this machine",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		out := cmd.OutOrStdout()
+		records, err := autostack.Projects()
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			fmt.Fprintln(out, "No projects recorded yet.")
+			return nil
+		}
+
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tSTACK\tPORTS\tPATH\tCREATED")
+		for _, r := range records {
+			path := r.Path
+			if !r.Exists() {
+				path += " (missing)"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.Name, r.Stack, formatPorts(r.Ports), path, r.Created.Local().Format("2006-01-02 15:04"))
+		}
+		return w.Flush()
+	},
+}
+
+var projectsPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Forget projects whose directory no longer exists",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		out := cmd.OutOrStdout()
+		removed, err := autostack.PruneProjects()
+		if err != nil {
+			return err
+		}
+		for _, r := range removed {
+			fmt.Fprintf(out, "Removed %s (%s)\n", r.Name, r.Path)
+		}
+		fmt.Fprintf(out, "%d project(s) pruned\n", len(removed))
+		return nil
+	},
+}
+
+var projectsShowCmd = &cobra.Command{
+	Use:   "show <project>",
+	Short: "Show the details of a recorded project",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		out := cmd.OutOrStdout()
+		r, err := autostack.FindRecord(args[0])
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(out, "Name:    %s\n", r.Name)
+		fmt.Fprintf(out, "Stack:   %s\n", r.Stack)
+		fmt.Fprintf(out, "Path:    %s\n", r.Path)
+		fmt.Fprintf(out, "Created: %s\n", r.Created.Local().Format("2006-01-02 15:04:05"))
+		if !r.Exists() {
+			fmt.Fprintln(out, "Status:  directory missing, run 'autostack projects prune' to forget it")
+		}
+
+		if len(r.Ports) > 0 {
+			fmt.Fprintln(out, "\nPorts:")
+			for _, label := range sortedKeys(r.Ports) {
+				fmt.Fprintf(out, "  %s: %s\n", label, r.Ports[label])
+			}
+		}
+
+		if lock, err := autostack.ReadLock(r.Path); err == nil {
+			fmt.Fprintf(out, "\nTemplate: %s %s\n", lock.Stack.Version, lock.Stack.Hash)
+			fmt.Fprintf(out, "Generated by autostack %s\n", lock.Autostack)
+		}
+		return nil
+	},
+}
+
+// formatPorts renders ports as "label=port" pairs sorted by label
+func formatPorts(ports map[string]string) string {
+	var pairs []string
+	for _, label := range sortedKeys(ports) {
+		pairs = append(pairs, label+"="+ports[label])
+	}
+	return strings.Join(pairs, ", ")
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func init() {
+	projectsCmd.AddCommand(projectsPruneCmd, projectsShowCmd)
+	rootCmd.AddCommand(projectsCmd)
+}
@@ -1,19 +1,69 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/bait-py/autostack/pkg/autostack"
 
 	"github.com/spf13/cobra"
 )
 
 var rootCmd = &cobra.Command{
-	Use:   "autostack",
-	Short: "AutoStack CLI",
+	Use:     "autostack",
+	Short:   "AutoStack CLI",
+	Version: autostack.Version(),
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		// User-defined stacks behave exactly like built-in ones. A broken
+		// stack is only a warning, commands that do not use it still work.
+		if err := autostack.LoadUserStacks(); err != nil {
+			newUI(cmd).Warnf("%v\n", err)
+		}
+	},
 	Run: func(cmd *cobra.Command, args []string) {
 		fmt.Println("AutoStack CLI. Use -h for help.")
 	},
 }
 
+// composeName selects the compose implementation, see autostack.NewRunner
+var composeName string
+
+// quiet and verbose select the output level, see autostack.Level
+var quiet, verbose bool
+
 func Execute() error {
-	return rootCmd.Execute()
+	// Interrupting autostack also stops the compose commands it runs
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	return rootCmd.ExecuteContext(ctx)
+}
+
+// composeRunner returns the compose implementation chosen by --compose,
+// AUTOSTACK_COMPOSE or detection
+func composeRunner(cmd *cobra.Command) (autostack.Runner, error) {
+	return autostack.NewRunner(cmd.Context(), composeName, newUI(cmd))
+}
+
+// newUI returns a UI on the streams of cmd at the level chosen by --quiet
+// or --verbose
+func newUI(cmd *cobra.Command) *autostack.UI {
+	ui := autostack.NewUI(cmd.InOrStdin(), cmd.OutOrStdout(), cmd.ErrOrStderr())
+	switch {
+	case quiet:
+		ui.Level = autostack.Quiet
+	case verbose:
+		ui.Level = autostack.Verbose
+	}
+	return ui
+}
+
+func init() {
+	flags := rootCmd.PersistentFlags()
+	flags.StringVar(&composeName, "compose", "", "compose implementation: auto, docker, docker-compose or podman (default $"+autostack.ComposeEnv+" or auto)")
+	flags.BoolVarP(&quiet, "quiet", "q", false, "only print prompts, warnings and errors")
+	flags.BoolVar(&verbose, "verbose", false, "print every step")
+	rootCmd.MarkFlagsMutuallyExclusive("quiet", "verbose")
 }
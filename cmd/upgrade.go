@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"github.com/bait-py/autostack/pkg/autostack"
+
+	"github.com/spf13/cobra"
+)
+
+var upgradeOpts autostack.UpgradeOptions
+
+var upgradeCmd = &cobra.Command{
+	Use:   "upgrade [dir]",
+	Short: "Upgrade a generated project to the current version of its stack",
+	Long: `Upgrade re-renders the original template of a generated project and the
+current one with the values recorded in its lockfile, then three-way merges
+the template changes into the files on disk. Local edits are kept; regions
+changed on both sides are left with conflict markers.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		dir := "."
+		if len(args) > 0 {
+			dir = args[0]
+		}
+		upgradeOpts.UI = newUI(cmd)
+		return autostack.Upgrade(dir, upgradeOpts)
+	},
+}
+
+func init() {
+	upgradeCmd.Flags().BoolVar(&upgradeOpts.DryRun, "dry-run", false, "show what would change without writing anything")
+
+	rootCmd.AddCommand(upgradeCmd)
+}
@@ -0,0 +1,130 @@
+// Package compose runs Docker Compose commands through whichever compose
+// implementation is available on the host.
+package compose
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Runner names accepted by New
+const (
+	Auto          = "auto"
+	DockerV2      = "docker"
+	DockerV1      = "docker-compose"
+	Podman        = "podman"
+	defaultRunner = DockerV2
+)
+
+// Env selects the runner when no flag is given
+const Env = "AUTOSTACK_COMPOSE"
+
+// Runner runs compose commands in a project directory
+type Runner interface {
+	// Name is the command line used to invoke compose, e.g. "docker compose"
+	Name() string
+	// Run runs compose with args in dir, streaming its output
+	Run(ctx context.Context, dir string, args ...string) error
+}
+
+// Command is a Runner backed by an external compose binary
+type Command struct {
+	Argv   []string // binary and leading arguments, e.g. ["docker", "compose"]
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// NewCommand returns a Command streaming to the standard streams
+func NewCommand(argv ...string) *Command {
+	return &Command{
+		Argv:   argv,
+		Stdin:  os.Stdin,
+		Stdout: os.Stdout,
+		Stderr: os.Stderr,
+	}
+}
+
+// Name implements Runner
+func (c *Command) Name() string {
+	return strings.Join(c.Argv, " ")
+}
+
+// Run implements Runner. The directory is passed to the process rather than
+// through a shell, so paths with spaces or shell characters are safe.
+func (c *Command) Run(ctx context.Context, dir string, args ...string) error {
+	argv := append(append([]string(nil), c.Argv[1:]...), args...)
+	cmd := exec.CommandContext(ctx, c.Argv[0], argv...)
+	cmd.Dir = dir
+	cmd.Stdin = c.Stdin
+	cmd.Stdout = c.Stdout
+	cmd.Stderr = c.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s %s: %w", c.Name(), strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+// available reports whether the compose implementation answers to version
+func (c *Command) available(ctx context.Context) bool {
+	argv := append(append([]string(nil), c.Argv[1:]...), "version")
+	return exec.CommandContext(ctx, c.Argv[0], argv...).Run() == nil
+}
+
+// candidates are the known implementations, streaming to the given streams
+func candidates(in io.Reader, out, errOut io.Writer) map[string]*Command {
+	all := map[string]*Command{
+		DockerV2: NewCommand("docker", "compose"),
+		DockerV1: NewCommand("docker-compose"),
+		Podman:   NewCommand("podman", "compose"),
+	}
+	for _, c := range all {
+		c.Stdin, c.Stdout, c.Stderr = in, out, errOut
+	}
+	return all
+}
+
+// New returns the runner with the given name, streaming to the standard
+// streams. An empty name uses the AUTOSTACK_COMPOSE environment variable,
+// and "auto" detects the runner.
+func New(ctx context.Context, name string) (Runner, error) {
+	return NewWithStreams(ctx, name, os.Stdin, os.Stdout, os.Stderr)
+}
+
+// NewWithStreams is New with the streams given to the compose process
+func NewWithStreams(ctx context.Context, name string, in io.Reader, out, errOut io.Writer) (Runner, error) {
+	if name == "" {
+		name = os.Getenv(Env)
+	}
+	all := candidates(in, out, errOut)
+	if name == "" || name == Auto {
+		return detect(ctx, all), nil
+	}
+
+	runner, ok := all[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown compose runner %q: use %s, %s, %s or %s", name, Auto, DockerV2, DockerV1, Podman)
+	}
+	return runner, nil
+}
+
+// Detect returns the first compose implementation available on the host:
+// docker compose, docker-compose, then podman compose. When none answers,
+// docker compose is returned so errors point at the most common setup.
+func Detect(ctx context.Context) Runner {
+	return detect(ctx, candidates(os.Stdin, os.Stdout, os.Stderr))
+}
+
+func detect(ctx context.Context, all map[string]*Command) Runner {
+	for _, name := range []string{DockerV2, DockerV1, Podman} {
+		if all[name].available(ctx) {
+			return all[name]
+		}
+	}
+	return all[defaultRunner]
+}
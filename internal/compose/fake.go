@@ -0,0 +1,38 @@
+package compose
+
+import (
+	"context"
+	"strings"
+)
+
+// Call is a command recorded by Fake
+type Call struct {
+	Dir  string
+	Args []string
+}
+
+// String returns the call as it would be typed
+func (c Call) String() string {
+	return strings.Join(c.Args, " ")
+}
+
+// Fake is a Runner that records calls instead of running anything.
+// It is meant for tests and for embedding autostack without Docker.
+type Fake struct {
+	Calls []Call
+	Err   error // returned by every Run
+}
+
+// Name implements Runner
+func (f *Fake) Name() string {
+	return "fake compose"
+}
+
+// Run implements Runner
+func (f *Fake) Run(ctx context.Context, dir string, args ...string) error {
+	f.Calls = append(f.Calls, Call{Dir: dir, Args: args})
+	if f.Err != nil {
+		return f.Err
+	}
+	return ctx.Err()
+}
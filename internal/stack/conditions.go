@@ -0,0 +1,151 @@
+package stack
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// condition is a parsed `when` expression. It is one of NAME, !NAME,
+// NAME == value or NAME != value, where NAME is an earlier variable.
+type condition struct {
+	name  string
+	op    string // "", "!", "==" or "!="
+	value string
+}
+
+// conditionName matches the variable referenced by a condition
+var conditionName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// parseCondition parses a `when` expression
+func parseCondition(expr string) (condition, error) {
+	expr = strings.TrimSpace(expr)
+
+	var c condition
+	switch {
+	case strings.Contains(expr, "=="), strings.Contains(expr, "!="):
+		c.op = "=="
+		if strings.Contains(expr, "!=") {
+			c.op = "!="
+		}
+		name, value, _ := strings.Cut(expr, c.op)
+		c.name = strings.TrimSpace(name)
+		c.value = strings.Trim(strings.TrimSpace(value), `"'`)
+	case strings.HasPrefix(expr, "!"):
+		c.op = "!"
+		c.name = strings.TrimSpace(expr[1:])
+	default:
+		c.name = expr
+	}
+
+	if !conditionName.MatchString(c.name) {
+		return condition{}, fmt.Errorf("invalid condition %q: use NAME, !NAME, NAME == value or NAME != value", expr)
+	}
+	return c, nil
+}
+
+// eval evaluates the condition against the values chosen so far. A variable
+// without a value is false.
+func (c condition) eval(values map[string]string) bool {
+	value := values[c.name]
+	switch c.op {
+	case "==":
+		return value == c.value
+	case "!=":
+		return value != c.value
+	case "!":
+		return !truthy(value)
+	default:
+		return truthy(value)
+	}
+}
+
+// truthy reports whether a value enables a condition: anything but an
+// empty value or a false boolean
+func truthy(value string) bool {
+	if value == "" {
+		return false
+	}
+	if b, err := strconv.ParseBool(value); err == nil {
+		return b
+	}
+	return true
+}
+
+// evalWhen evaluates an optional `when` expression, empty means always
+func evalWhen(expr string, values map[string]string) bool {
+	if expr == "" {
+		return true
+	}
+	c, err := parseCondition(expr)
+	if err != nil {
+		return false
+	}
+	return c.eval(values)
+}
+
+// Active reports whether the variable applies given the values chosen so far
+func (v StackEnvVars) Active(values map[string]string) bool {
+	return evalWhen(v.When, values)
+}
+
+// Active reports whether the port applies given the variable values
+func (p StackPort) Active(values map[string]string) bool {
+	return evalWhen(p.When, values)
+}
+
+// PathCondition generates the files and directories matching Pattern, a
+// path.Match pattern relative to the project, only when When is true
+type PathCondition struct {
+	Pattern string
+	When    string
+}
+
+// ActivePorts returns the ports that apply given the variable values
+func (s Stack) ActivePorts(values map[string]string) []StackPort {
+	var ports []StackPort
+	for _, p := range s.Ports {
+		if p.Active(values) {
+			ports = append(ports, p)
+		}
+	}
+	return ports
+}
+
+// checkConditions verifies that every `when` expression parses and only
+// references variables declared before it
+func checkConditions(vars []StackEnvVars, ports []StackPort, paths []PathCondition) error {
+	declared := make(map[string]bool)
+	check := func(owner, expr string) error {
+		if expr == "" {
+			return nil
+		}
+		c, err := parseCondition(expr)
+		if err != nil {
+			return fmt.Errorf("%s: %w", owner, err)
+		}
+		if !declared[c.name] {
+			return fmt.Errorf("%s: condition references %s, which is not declared before it", owner, c.name)
+		}
+		return nil
+	}
+
+	for _, v := range vars {
+		if err := check(v.VarName, v.When); err != nil {
+			return err
+		}
+		declared[v.VarName] = true
+	}
+	for _, p := range ports {
+		if err := check("port "+p.ServiceName, p.When); err != nil {
+			return err
+		}
+	}
+	for _, p := range paths {
+		if err := check(p.Pattern, p.When); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package stack
+
+import "testing"
+
+func TestParseCondition(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    condition
+		wantErr bool
+	}{
+		{expr: "LOKI_ENABLED", want: condition{name: "LOKI_ENABLED"}},
+		{expr: "  LOKI_ENABLED  ", want: condition{name: "LOKI_ENABLED"}},
+		{expr: "!LOKI_ENABLED", want: condition{name: "LOKI_ENABLED", op: "!"}},
+		{expr: "! LOKI_ENABLED", want: condition{name: "LOKI_ENABLED", op: "!"}},
+		{expr: "BACKEND == tempo", want: condition{name: "BACKEND", op: "==", value: "tempo"}},
+		{expr: "BACKEND==tempo", want: condition{name: "BACKEND", op: "==", value: "tempo"}},
+		{expr: "BACKEND != tempo", want: condition{name: "BACKEND", op: "!=", value: "tempo"}},
+		{expr: `BACKEND == "tempo"`, want: condition{name: "BACKEND", op: "==", value: "tempo"}},
+		{expr: "BACKEND == 'tempo'", want: condition{name: "BACKEND", op: "==", value: "tempo"}},
+		{expr: "BACKEND ==", want: condition{name: "BACKEND", op: "=="}},
+		{expr: "", wantErr: true},
+		{expr: "!", wantErr: true},
+		{expr: "1BACKEND", wantErr: true},
+		{expr: "LOKI ENABLED", wantErr: true},
+		{expr: "== tempo", wantErr: true},
+		{expr: "BACKEND = tempo", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := parseCondition(tt.expr)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseCondition(%q) = %+v, want an error", tt.expr, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseCondition(%q): %v", tt.expr, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseCondition(%q) = %+v, want %+v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConditionEval(t *testing.T) {
+	tests := []struct {
+		expr   string
+		values map[string]string
+		want   bool
+	}{
+		{expr: "ENABLED", values: map[string]string{"ENABLED": "true"}, want: true},
+		{expr: "ENABLED", values: map[string]string{"ENABLED": "1"}, want: true},
+		{expr: "ENABLED", values: map[string]string{"ENABLED": "yes"}, want: true},
+		{expr: "ENABLED", values: map[string]string{"ENABLED": "false"}, want: false},
+		{expr: "ENABLED", values: map[string]string{"ENABLED": "0"}, want: false},
+		{expr: "ENABLED", values: map[string]string{"ENABLED": ""}, want: false},
+		{expr: "ENABLED", values: map[string]string{}, want: false},
+		{expr: "!ENABLED", values: map[string]string{"ENABLED": "true"}, want: false},
+		{expr: "!ENABLED", values: map[string]string{"ENABLED": "false"}, want: true},
+		{expr: "!ENABLED", values: map[string]string{}, want: true},
+		{expr: "BACKEND == tempo", values: map[string]string{"BACKEND": "tempo"}, want: true},
+		{expr: "BACKEND == tempo", values: map[string]string{"BACKEND": "jaeger"}, want: false},
+		{expr: "BACKEND == tempo", values: map[string]string{}, want: false},
+		{expr: "BACKEND != tempo", values: map[string]string{"BACKEND": "jaeger"}, want: true},
+		{expr: "BACKEND != tempo", values: map[string]string{"BACKEND": "tempo"}, want: false},
+		{expr: "BACKEND != tempo", values: map[string]string{}, want: true},
+	}
+
+	for _, tt := range tests {
+		c, err := parseCondition(tt.expr)
+		if err != nil {
+			t.Fatalf("parseCondition(%q): %v", tt.expr, err)
+		}
+		if got := c.eval(tt.values); got != tt.want {
+			t.Errorf("%q with %v = %v, want %v", tt.expr, tt.values, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package stack
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 3
+
+// editKind is the kind of a single line edit
+type editKind int
+
+const (
+	editEqual editKind = iota
+	editDelete
+	editInsert
+)
+
+// edit is a single line of a diff between two texts
+type edit struct {
+	kind editKind
+	line string
+	a, b int // line index in the old and new text
+}
+
+// splitLines splits text into lines, keeping the line endings so a missing
+// final newline is not lost
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns the edits turning a into b using the longest common
+// subsequence of lines. Generated files are small, so the quadratic table
+// is not a concern.
+func diffLines(a, b []string) []edit {
+	n, m := len(a), len(b)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var edits []edit
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && a[i] == b[j]:
+			edits = append(edits, edit{editEqual, a[i], i, j})
+			i++
+			j++
+		case j < m && (i == n || lcs[i][j+1] > lcs[i+1][j]):
+			edits = append(edits, edit{editInsert, b[j], i, j})
+			j++
+		default:
+			edits = append(edits, edit{editDelete, a[i], i, j})
+			i++
+		}
+	}
+	return edits
+}
+
+// UnifiedDiff returns a unified diff between two texts, or an empty string
+// when they are equal
+func UnifiedDiff(oldName, newName, oldText, newText string) string {
+	if oldText == newText {
+		return ""
+	}
+
+	edits := diffLines(splitLines(oldText), splitLines(newText))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+
+	for start := 0; start < len(edits); {
+		// Find the next change
+		for start < len(edits) && edits[start].kind == editEqual {
+			start++
+		}
+		if start == len(edits) {
+			break
+		}
+
+		// Extend the hunk while changes are close enough to share context
+		first := max(start-diffContext, 0)
+		end := start
+		for end < len(edits) {
+			if edits[end].kind != editEqual {
+				end++
+				continue
+			}
+			next := end
+			for next < len(edits) && edits[next].kind == editEqual {
+				next++
+			}
+			if next == len(edits) || next-end > 2*diffContext {
+				break
+			}
+			end = next
+		}
+		last := min(end+diffContext, len(edits))
+
+		writeHunk(&b, edits[first:last])
+		start = last
+	}
+
+	return b.String()
+}
+
+// writeHunk writes a single unified diff hunk
+func writeHunk(b *strings.Builder, hunk []edit) {
+	oldStart, newStart := hunk[0].a, hunk[0].b
+	var oldCount, newCount int
+	for _, e := range hunk {
+		if e.kind != editInsert {
+			oldCount++
+		}
+		if e.kind != editDelete {
+			newCount++
+		}
+	}
+	if oldCount > 0 {
+		oldStart++
+	}
+	if newCount > 0 {
+		newStart++
+	}
+
+	fmt.Fprintf(b, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+	for _, e := range hunk {
+		prefix := " "
+		switch e.kind {
+		case editDelete:
+			prefix = "-"
+		case editInsert:
+			prefix = "+"
+		}
+		b.WriteString(prefix + e.line)
+		if !strings.HasSuffix(e.line, "\n") {
+			b.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
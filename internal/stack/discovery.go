@@ -0,0 +1,90 @@
+package stack
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Stack sources, from lowest to highest precedence
+const (
+	SourceBuiltin = "built-in"
+	SourceUser    = "user"
+	SourcePath    = "AUTOSTACK_PATH"
+	SourceProject = "project"
+)
+
+// PathEnv is the environment variable holding extra stack directories
+const PathEnv = "AUTOSTACK_PATH"
+
+// ProjectStacksDir is the project-local stacks directory
+const ProjectStacksDir = ".autostack/stacks"
+
+// SearchDir is a directory scanned for user-defined stacks
+type SearchDir struct {
+	Path   string
+	Source string
+}
+
+// SearchDirs returns the directories scanned for user-defined stacks,
+// from highest to lowest precedence:
+//
+//  1. ./.autostack/stacks
+//  2. each entry of AUTOSTACK_PATH, in order
+//  3. ~/.config/autostack/stacks
+//
+// Built-in stacks have the lowest precedence of all.
+func SearchDirs() []SearchDir {
+	dirs := []SearchDir{{Path: ProjectStacksDir, Source: SourceProject}}
+
+	for _, dir := range filepath.SplitList(os.Getenv(PathEnv)) {
+		if dir != "" {
+			dirs = append(dirs, SearchDir{Path: dir, Source: SourcePath})
+		}
+	}
+
+	if configDir, err := os.UserConfigDir(); err == nil {
+		dirs = append(dirs, SearchDir{
+			Path:   filepath.Join(configDir, "autostack", "stacks"),
+			Source: SourceUser,
+		})
+	}
+
+	return dirs
+}
+
+// LoadUserStacks registers the stacks found in SearchDirs. A stack with
+// the same name as one from a lower-precedence source replaces it. Stacks
+// that cannot be loaded are skipped, so one broken manifest does not hide
+// the others: the returned error lists them once every other stack is
+// registered.
+func LoadUserStacks() error {
+	dirs := SearchDirs()
+	var problems []error
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		fsys := os.DirFS(dirs[i].Path)
+		stackPaths, err := stackDirs(fsys, ".")
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			problems = append(problems, fmt.Errorf("error loading stacks from %s: %w", dirs[i].Path, err))
+			continue
+		}
+
+		for _, dir := range stackPaths {
+			s, err := LoadStack(fsys, dir)
+			if err != nil {
+				problems = append(problems, fmt.Errorf("skipping stack in %s: %w", dirs[i].Path, err))
+				continue
+			}
+			s.Source = dirs[i].Source
+			Replace(s)
+		}
+	}
+
+	return errors.Join(problems...)
+}
@@ -0,0 +1,122 @@
+package stack
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSearchDirs(t *testing.T) {
+	sep := string(filepath.ListSeparator)
+	tests := []struct {
+		name string
+		path string
+		want []SearchDir
+	}{
+		{
+			name: "without AUTOSTACK_PATH",
+			want: []SearchDir{
+				{Path: ProjectStacksDir, Source: SourceProject},
+				{Path: "/config/autostack/stacks", Source: SourceUser},
+			},
+		},
+		{
+			name: "AUTOSTACK_PATH entries in order",
+			path: "/first" + sep + "/second",
+			want: []SearchDir{
+				{Path: ProjectStacksDir, Source: SourceProject},
+				{Path: "/first", Source: SourcePath},
+				{Path: "/second", Source: SourcePath},
+				{Path: "/config/autostack/stacks", Source: SourceUser},
+			},
+		},
+		{
+			name: "empty AUTOSTACK_PATH entries skipped",
+			path: sep + "/first" + sep + sep,
+			want: []SearchDir{
+				{Path: ProjectStacksDir, Source: SourceProject},
+				{Path: "/first", Source: SourcePath},
+				{Path: "/config/autostack/stacks", Source: SourceUser},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("XDG_CONFIG_HOME", "/config")
+			t.Setenv(PathEnv, tt.path)
+
+			if got := SearchDirs(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SearchDirs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadUserStacksPrecedence(t *testing.T) {
+	const (
+		userDir    = "config/autostack/stacks"
+		projectDir = "project/" + ProjectStacksDir
+	)
+	tests := []struct {
+		name string
+		dirs []string // directories under the test root holding a demo stack described by their path
+		want string   // description of the demo stack found
+	}{
+		{name: "built-in only", want: SourceBuiltin},
+		{name: "user over built-in", dirs: []string{userDir}, want: userDir},
+		{name: "AUTOSTACK_PATH over user", dirs: []string{userDir, "path2"}, want: "path2"},
+		{name: "first AUTOSTACK_PATH entry first", dirs: []string{"path1", "path2"}, want: "path1"},
+		{name: "project over everything", dirs: []string{userDir, "path1", projectDir}, want: projectDir},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useRegistry(t, Stack{Name: "demo", Description: SourceBuiltin, Source: SourceBuiltin})
+
+			root := t.TempDir()
+			for _, dir := range tt.dirs {
+				stackDir := filepath.Join(root, dir, "demo")
+				if err := os.MkdirAll(stackDir, 0755); err != nil {
+					t.Fatal(err)
+				}
+				manifest := "name: demo\ndescription: " + dir + "\n"
+				if err := os.WriteFile(filepath.Join(stackDir, ManifestFile), []byte(manifest), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			t.Setenv("XDG_CONFIG_HOME", filepath.Join(root, "config"))
+			t.Setenv(PathEnv, filepath.Join(root, "path1")+string(filepath.ListSeparator)+filepath.Join(root, "path2"))
+			chdir(t, filepath.Join(root, "project"))
+
+			if err := LoadUserStacks(); err != nil {
+				t.Fatalf("LoadUserStacks: %v", err)
+			}
+			s, ok := Lookup("demo")
+			if !ok {
+				t.Fatal("demo is not registered")
+			}
+			if s.Description != tt.want {
+				t.Errorf("demo comes from %s (%s), want the one from %s", s.Description, s.Source, tt.want)
+			}
+		})
+	}
+}
+
+// chdir changes the working directory to dir, created when missing, for
+// the duration of the test
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+}
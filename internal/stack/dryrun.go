@@ -0,0 +1,235 @@
+package stack
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// secretMask replaces secret values in dry-run output
+const secretMask = "********"
+
+// PrintDryRun shows what GenerateStack would do without touching disk.
+// When the project directory exists a unified diff against it is shown,
+// otherwise the file tree and the content of every file. Secret values are
+// masked, so the output can be shared.
+func PrintDryRun(ui *UI, config StackConfig) error {
+	info, err := os.Stat(config.ProjectDir)
+	if err == nil && info.IsDir() {
+		return printDiff(ui, config)
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	fmt.Fprintf(ui.Out, "\n=== Dry run: %s stack ===\n\n", config.Name)
+	printTree(ui, config)
+
+	mask := newSecretMasker(config)
+	for _, relPath := range config.Paths() {
+		content := mask.apply(relPath, config.Files[relPath])
+		fmt.Fprintf(ui.Out, "\n=== %s ===\n", relPath)
+		fmt.Fprint(ui.Out, content)
+		if !strings.HasSuffix(content, "\n") {
+			fmt.Fprintln(ui.Out)
+		}
+	}
+	fmt.Fprintln(ui.Out)
+
+	return nil
+}
+
+// printDiff shows a unified diff between the project directory and the
+// rendered files
+func printDiff(ui *UI, config StackConfig) error {
+	fmt.Fprintf(ui.Out, "\n=== Dry run: changes to %s ===\n\n", config.ProjectDir)
+
+	mask := newSecretMasker(config)
+	changed := 0
+	for _, relPath := range config.Paths() {
+		oldName := path.Join("a", relPath)
+		newName := path.Join("b", relPath)
+
+		current, err := os.ReadFile(filepath.Join(config.ProjectDir, relPath))
+		if errors.Is(err, fs.ErrNotExist) {
+			oldName = "/dev/null"
+		} else if err != nil {
+			return err
+		}
+
+		if config.Existing == MergeExisting && oldName != "/dev/null" {
+			fmt.Fprintf(ui.Out, "# %s exists and would be kept\n", relPath)
+			continue
+		}
+
+		diff := UnifiedDiff(oldName, newName, string(current), config.Files[relPath])
+		if diff == "" {
+			continue
+		}
+		fmt.Fprint(ui.Out, mask.applyDiff(relPath, diff))
+		changed++
+	}
+
+	if changed == 0 {
+		fmt.Fprintln(ui.Out, "No changes.")
+	}
+	fmt.Fprintln(ui.Out)
+
+	return nil
+}
+
+// secretMasker hides secret values in the files shown by a dry run. The
+// files holding a secret are known from the variables their templates
+// print, and their lines are replaced by those rendered with the secrets
+// masked.
+type secretMasker struct {
+	names  map[string]bool              // secret variables, masked in .env
+	masked map[string]map[string]string // relative path -> real line -> masked line
+}
+
+// newSecretMasker masks the secrets chosen for config and those of the
+// files the lock of an existing project left out as holding a secret
+func newSecretMasker(config StackConfig) secretMasker {
+	m := secretMasker{
+		names:  make(map[string]bool),
+		masked: make(map[string]map[string]string),
+	}
+	for _, v := range config.EnvVars {
+		if v.Secret() {
+			m.names[v.VarName] = true
+		}
+	}
+
+	for relPath, masked := range config.Masked {
+		lines := make(map[string]string)
+		real := strings.Split(config.Files[relPath], "\n")
+		maskedLines := strings.Split(masked, "\n")
+		if len(real) == len(maskedLines) {
+			for i, line := range real {
+				lines[line] = maskedLines[i]
+			}
+		}
+		m.masked[relPath] = lines
+	}
+
+	if lock, err := ReadLock(config.ProjectDir); err == nil {
+		for _, relPath := range config.Paths() {
+			if _, ok := lock.Files[relPath]; ok || m.masked[relPath] != nil {
+				continue
+			}
+			lines := make(map[string]string)
+			for _, line := range strings.Split(config.Files[relPath], "\n") {
+				lines[line] = line
+			}
+			m.masked[relPath] = lines
+		}
+	}
+	return m
+}
+
+// apply masks the secrets in the content of a file
+func (m secretMasker) apply(relPath, content string) string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		lines[i] = m.line(relPath, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// applyDiff masks the secrets in the diff of a file. The diff is computed
+// on the real content, so a changed secret still shows as changed.
+func (m secretMasker) applyDiff(relPath, diff string) string {
+	lines := strings.Split(diff, "\n")
+	for i, line := range lines {
+		switch {
+		case line == "", strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"),
+			strings.HasPrefix(line, "@@"), strings.HasPrefix(line, `\`):
+			continue
+		}
+		lines[i] = line[:1] + m.line(relPath, line[1:])
+	}
+	return strings.Join(lines, "\n")
+}
+
+// line masks a single line of a file. Secret files are hidden whole and
+// secret variables of .env whatever their quoting. The lines of the other
+// files holding a secret are shown as rendered with the secrets masked,
+// and a line that was not rendered, such as one of the existing file, is
+// hidden.
+func (m secretMasker) line(relPath, line string) string {
+	if line == "" {
+		return line
+	}
+	if strings.HasPrefix(relPath, SecretsDir+"/") {
+		return secretMask
+	}
+	if relPath == EnvFile {
+		if key, _, ok := strings.Cut(line, "="); ok && m.names[key] {
+			return key + "=" + secretMask
+		}
+		return line
+	}
+	lines, ok := m.masked[relPath]
+	if !ok {
+		return line
+	}
+	if masked, found := lines[line]; found {
+		return masked
+	}
+	return secretMask
+}
+
+// printTree prints the directories and files of the stack as a tree
+func printTree(ui *UI, config StackConfig) {
+	children := make(map[string][]string)
+	seen := make(map[string]bool)
+
+	var addPath func(p string, isDir bool)
+	addPath = func(p string, isDir bool) {
+		if seen[p] {
+			return
+		}
+		seen[p] = true
+
+		parent := path.Dir(p)
+		name := path.Base(p)
+		if isDir {
+			name += "/"
+		}
+		children[parent] = append(children[parent], name)
+		if parent != "." {
+			addPath(parent, true)
+		}
+	}
+
+	for _, dir := range config.Dirs {
+		addPath(path.Clean(dir), true)
+	}
+	for _, relPath := range config.Paths() {
+		addPath(relPath, false)
+	}
+
+	var walk func(dir, prefix string)
+	walk = func(dir, prefix string) {
+		entries := children[dir]
+		sort.Strings(entries)
+		for i, entry := range entries {
+			branch, next := "├── ", "│   "
+			if i == len(entries)-1 {
+				branch, next = "└── ", "    "
+			}
+			fmt.Fprintln(ui.Out, prefix+branch+entry)
+			if strings.HasSuffix(entry, "/") {
+				walk(path.Join(dir, strings.TrimSuffix(entry, "/")), prefix+next)
+			}
+		}
+	}
+
+	fmt.Fprintln(ui.Out, config.ProjectDir+"/")
+	walk(".", "")
+}
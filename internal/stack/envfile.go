@@ -0,0 +1,127 @@
+package stack
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Files written when values live in .env
+const (
+	EnvFile        = ".env"
+	EnvExampleFile = ".env.example"
+	gitignoreFile  = ".gitignore"
+)
+
+// secretPlaceholder replaces secrets in .env.example
+const secretPlaceholder = "change-me"
+
+// plainEnvValue matches values that need no quoting in a .env file
+var plainEnvValue = regexp.MustCompile(`^[A-Za-z0-9_./:@+,-]*$`)
+
+// AddEnvFile appends the variables referenced with ref to the rendered
+// .env, writes a .env.example with placeholders for sharing and keeps .env
+// out of git. Variables only used while rendering, such as the flags of
+// optional services, are left out since compose never reads them. The .env
+// template of the stack, if any, is kept at the top of both files. Nothing
+// is added when no variable goes to .env.
+func (config *StackConfig) AddEnvFile(values map[string]string, refs map[string]bool) {
+	var vars []StackEnvVars
+	for _, v := range config.EnvVars {
+		if refs[v.VarName] && v.Active(values) {
+			vars = append(vars, v)
+		}
+	}
+	if len(vars) == 0 {
+		return
+	}
+
+	header := config.Files[EnvFile]
+	if header != "" && !strings.HasSuffix(header, "\n") {
+		header += "\n"
+	}
+
+	var env, example strings.Builder
+	env.WriteString(header)
+	example.WriteString(header)
+	if header != "" {
+		env.WriteString("\n")
+		example.WriteString("\n")
+	}
+
+	for _, v := range vars {
+		if v.Description != "" {
+			fmt.Fprintf(&env, "# %s\n", v.Description)
+			fmt.Fprintf(&example, "# %s\n", v.Description)
+		}
+		fmt.Fprintf(&env, "%s=%s\n", v.VarName, quoteEnvValue(values[v.VarName]))
+
+		placeholder := v.Default
+		if v.Secret() {
+			placeholder = secretPlaceholder
+		}
+		fmt.Fprintf(&example, "%s=%s\n", v.VarName, quoteEnvValue(placeholder))
+	}
+
+	config.Files[EnvFile] = env.String()
+	config.Files[EnvExampleFile] = example.String()
+
+	// Never commit the real values
+	config.ignore(EnvFile)
+}
+
+// ignore adds pattern to the generated .gitignore unless it is already there
+func (config *StackConfig) ignore(pattern string) {
+	ignore := config.Files[gitignoreFile]
+	for _, line := range strings.Split(ignore, "\n") {
+		if strings.TrimSpace(line) == pattern {
+			return
+		}
+	}
+	if ignore != "" && !strings.HasSuffix(ignore, "\n") {
+		ignore += "\n"
+	}
+	config.Files[gitignoreFile] = ignore + pattern + "\n"
+}
+
+// fileMode returns the permissions of a generated file. The .env file and
+// secret files hold credentials and are only readable by their owner,
+// except the files of shared secrets: compose mounts secret files with
+// their host permissions, so a service running as another user, such as
+// www-data, could not read them otherwise.
+func (config StackConfig) fileMode(relPath string) os.FileMode {
+	for _, v := range config.EnvVars {
+		if v.Shared && relPath == SecretFile(v.VarName) {
+			return 0644
+		}
+	}
+	if relPath == EnvFile || strings.HasPrefix(relPath, SecretsDir+"/") {
+		return 0600
+	}
+	return 0644
+}
+
+// writeFile writes a generated file with the permissions of fileMode, also
+// when it already exists: os.WriteFile keeps the permissions of an existing
+// file, which would leave a secret unreadable after it became shared.
+func (config StackConfig) writeFile(fullPath, relPath, content string) error {
+	mode := config.fileMode(relPath)
+	if err := os.WriteFile(fullPath, []byte(content), mode); err != nil {
+		return err
+	}
+	return os.Chmod(fullPath, mode)
+}
+
+// quoteEnvValue quotes a value for a compose .env file. Single quotes keep
+// the value literal, so $ is not interpolated by compose.
+func quoteEnvValue(value string) string {
+	if plainEnvValue.MatchString(value) {
+		return value
+	}
+	if !strings.Contains(value, "'") {
+		return "'" + value + "'"
+	}
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `$$`).Replace(value)
+	return `"` + escaped + `"`
+}
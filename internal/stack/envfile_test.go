@@ -0,0 +1,113 @@
+package stack
+
+import "testing"
+
+func TestQuoteEnvValue(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "", want: ""},
+		{value: "lamp_db", want: "lamp_db"},
+		{value: "user@example.com", want: "user@example.com"},
+		{value: "http://loki:3100/api", want: "http://loki:3100/api"},
+		{value: "a-b.c,d+e", want: "a-b.c,d+e"},
+		{value: "two words", want: "'two words'"},
+		{value: "pa$$word", want: "'pa$$word'"},
+		{value: "#hash", want: "'#hash'"},
+		{value: `say "hi"`, want: `'say "hi"'`},
+		{value: `back\slash`, want: `'back\slash'`},
+		{value: "it's", want: `"it's"`},
+		{value: `it's "$HOME"`, want: `"it's \"$$HOME\""`},
+		{value: `it's a\b`, want: `"it's a\\b"`},
+	}
+
+	for _, tt := range tests {
+		if got := quoteEnvValue(tt.value); got != tt.want {
+			t.Errorf("quoteEnvValue(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestAddEnvFile(t *testing.T) {
+	vars := []StackEnvVars{
+		{VarName: "DB_NAME", Description: "Database name", Default: "app"},
+		{VarName: "DB_PASSWORD", Description: "Database password", Kind: KindSecret},
+		{VarName: "ADMIN_ENABLED", Kind: KindBool, Default: "true"},
+		{VarName: "ADMIN_PASSWORD", Kind: KindSecret, When: "ADMIN_ENABLED"},
+	}
+	values := map[string]string{
+		"DB_NAME":        "shop",
+		"DB_PASSWORD":    "s3cret pass",
+		"ADMIN_ENABLED":  "false",
+		"ADMIN_PASSWORD": "admin",
+	}
+
+	tests := []struct {
+		name        string
+		refs        map[string]bool
+		header      string
+		gitignore   string
+		wantEnv     string
+		wantExample string
+		wantIgnore  string
+	}{
+		{
+			name: "referenced variables only",
+			refs: map[string]bool{"DB_NAME": true, "DB_PASSWORD": true},
+			wantEnv: "# Database name\nDB_NAME=shop\n" +
+				"# Database password\nDB_PASSWORD='s3cret pass'\n",
+			wantExample: "# Database name\nDB_NAME=app\n" +
+				"# Database password\nDB_PASSWORD=change-me\n",
+			wantIgnore: ".env\n",
+		},
+		{
+			name:        "inactive variables left out",
+			refs:        map[string]bool{"DB_NAME": true, "ADMIN_PASSWORD": true},
+			wantEnv:     "# Database name\nDB_NAME=shop\n",
+			wantExample: "# Database name\nDB_NAME=app\n",
+			wantIgnore:  ".env\n",
+		},
+		{
+			name:        "template header kept",
+			refs:        map[string]bool{"DB_NAME": true},
+			header:      "COMPOSE_PROJECT_NAME=shop",
+			gitignore:   "data/\n.env\n",
+			wantEnv:     "COMPOSE_PROJECT_NAME=shop\n\n# Database name\nDB_NAME=shop\n",
+			wantExample: "COMPOSE_PROJECT_NAME=shop\n\n# Database name\nDB_NAME=app\n",
+			wantIgnore:  "data/\n.env\n",
+		},
+		{
+			name:       "nothing referenced",
+			refs:       map[string]bool{"ADMIN_ENABLED": false},
+			header:     "COMPOSE_PROJECT_NAME=shop\n",
+			gitignore:  "data/",
+			wantEnv:    "COMPOSE_PROJECT_NAME=shop\n",
+			wantIgnore: "data/",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := StackConfig{EnvVars: vars, Files: make(map[string]string)}
+			if tt.header != "" {
+				config.Files[EnvFile] = tt.header
+			}
+			if tt.gitignore != "" {
+				config.Files[gitignoreFile] = tt.gitignore
+			}
+
+			config.AddEnvFile(values, tt.refs)
+
+			if got := config.Files[EnvFile]; got != tt.wantEnv {
+				t.Errorf(".env =\n%s\nwant\n%s", got, tt.wantEnv)
+			}
+			if got := config.Files[EnvExampleFile]; got != tt.wantExample {
+				t.Errorf(".env.example =\n%s\nwant\n%s", got, tt.wantExample)
+			}
+			if got := config.Files[gitignoreFile]; got != tt.wantIgnore {
+				t.Errorf(".gitignore = %q, want %q", got, tt.wantIgnore)
+			}
+		})
+	}
+}
@@ -1,66 +1,134 @@
 package stack
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
+
+	"github.com/bait-py/autostack/internal/compose"
+)
+
+// ExistingPolicy decides what GenerateStack does with files that already exist
+type ExistingPolicy int
+
+const (
+	// RefuseExisting fails without writing anything
+	RefuseExisting ExistingPolicy = iota
+	// OverwriteExisting replaces existing files
+	OverwriteExisting
+	// MergeExisting keeps existing files and only adds missing ones
+	MergeExisting
 )
 
 // StackConfig defines the configuration to generate a stack
 type StackConfig struct {
 	Name           string
+	ProjectName    string // compose project name and container_name prefix
 	ProjectDir     string
 	Files          map[string]string // relative path -> content
 	Dirs           []string          // directories to create
+	Raw            []string          // patterns of files copied without rendering
+	Conditions     []PathCondition   // files and directories generated only when enabled
+	Masked         map[string]string // files holding a secret, rendered with the secrets masked
 	AutoStart      bool              // run docker-compose up -d automatically
 	Ports          map[string]string // service -> port (to display in summary)
 	Description    string            // stack description
 	EnvVars        []StackEnvVars    // configurable environment variables
 	ConfigurePorts []StackPort       // configurable ports
+	Existing       ExistingPolicy    // what to do with files that already exist
+	Lock           *Lock             // lockfile to write, nil to skip it
+	Runner         compose.Runner    // compose implementation used to start the stack
+
+	EnvValues        map[string]string // chosen value of every enabled variable
+	PortValues       map[string]string // service -> chosen host port
+	GeneratedSecrets map[string]string // secrets generated at random, shown once in the summary
+	EnvFile          bool              // write values to .env and reference them from compose
+	SecretFiles      bool              // write secrets to secrets/ and mount them as compose secrets
 }
 
-// ApplyEnvVars replaces environment variable placeholders in files
-func (config *StackConfig) ApplyEnvVars(values map[string]string) {
-	if len(values) == 0 {
-		return
+// ApplyPorts records the chosen host ports in the summary, keyed by the
+// label of each configurable port. Ports without a value are disabled.
+func (config *StackConfig) ApplyPorts(values map[string]string) {
+	for _, p := range config.ConfigurePorts {
+		if _, ok := values[p.ServiceName]; !ok {
+			continue
+		}
+		label := p.Label
+		if label == "" {
+			label = p.ServiceName
+		}
+		config.Ports[label] = values[p.ServiceName]
 	}
+}
 
-	// Replace placeholders in all files
-	for path, content := range config.Files {
-		for key, value := range values {
-			placeholder := "{{" + key + "}}"
-			content = strings.ReplaceAll(content, placeholder, value)
-		}
-		config.Files[path] = content
+// Record returns the registry entry of the generated project
+func (config StackConfig) Record() ProjectRecord {
+	record := ProjectRecord{
+		Name:  config.ProjectName,
+		Path:  config.ProjectDir,
+		Ports: config.Ports,
+	}
+	if config.Lock != nil {
+		record.Stack = config.Lock.Stack.Name
+		record.Created = config.Lock.Created
 	}
+	return record
 }
 
-// ApplyPorts replaces port placeholders in files
-func (config *StackConfig) ApplyPorts(values map[string]string) {
-	if len(values) == 0 {
-		return
+// Paths returns the relative paths of all files in sorted order
+func (config StackConfig) Paths() []string {
+	paths := make([]string, 0, len(config.Files))
+	for path := range config.Files {
+		paths = append(paths, path)
 	}
+	sort.Strings(paths)
+	return paths
+}
 
-	// Replace port placeholders and update display ports
-	for path, content := range config.Files {
-		for serviceName, hostPort := range values {
-			placeholder := "{{PORT_" + strings.ToUpper(serviceName) + "}}"
-			content = strings.ReplaceAll(content, placeholder, hostPort)
+// Conflicts returns the files that already exist in the project directory
+func (config StackConfig) Conflicts() []string {
+	var existing []string
+	for _, relPath := range config.Paths() {
+		if _, err := os.Stat(filepath.Join(config.ProjectDir, relPath)); err == nil {
+			existing = append(existing, relPath)
 		}
-		config.Files[path] = content
+	}
+	return existing
+}
+
+// CheckExisting returns an error listing the files that would be clobbered
+// when the policy is RefuseExisting
+func (config StackConfig) CheckExisting() error {
+	if config.Existing != RefuseExisting {
+		return nil
 	}
 
-	// Update ports map for display
-	for serviceName, hostPort := range values {
-		config.Ports[serviceName] = hostPort
+	conflicts := config.Conflicts()
+	if len(conflicts) == 0 {
+		return nil
 	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s already contains files that would be overwritten:\n", config.ProjectDir)
+	for _, file := range conflicts {
+		fmt.Fprintf(&b, "  - %s\n", file)
+	}
+	b.WriteString("use --force to overwrite them or --merge to only add missing files")
+	return errors.New(b.String())
 }
 
-// GenerateStack creates all necessary files and directories for a stack
-func GenerateStack(config StackConfig) error {
-	fmt.Printf("Generating files for %s stack...\n", config.Name)
+// GenerateStack creates all necessary files and directories for a stack.
+// When compose up fails the files are kept and a *StartError is returned.
+func GenerateStack(ctx context.Context, ui *UI, config StackConfig) error {
+	if err := config.CheckExisting(); err != nil {
+		return err
+	}
+
+	ui.Printf("Generating files for %s stack...\n", config.Name)
 
 	// Create main project directory
 	if err := os.MkdirAll(config.ProjectDir, 0755); err != nil {
@@ -70,70 +138,138 @@ func GenerateStack(config StackConfig) error {
 	// Create subdirectories
 	for _, dir := range config.Dirs {
 		fullPath := filepath.Join(config.ProjectDir, dir)
+		ui.Verbosef("Creating %s/\n", fullPath)
 		if err := os.MkdirAll(fullPath, 0755); err != nil {
 			return fmt.Errorf("error creating directory %s: %w", dir, err)
 		}
 	}
 
 	// Create files
-	var fileNames []string
-	for relPath, content := range config.Files {
+	var fileNames, skipped []string
+	for _, relPath := range config.Paths() {
 		fullPath := filepath.Join(config.ProjectDir, relPath)
-		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+		if config.Existing == MergeExisting {
+			if _, err := os.Stat(fullPath); err == nil {
+				ui.Verbosef("Keeping %s\n", fullPath)
+				skipped = append(skipped, relPath)
+				continue
+			}
+		}
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			return fmt.Errorf("error creating directory for %s: %w", relPath, err)
+		}
+		ui.Verbosef("Writing %s\n", fullPath)
+		if err := config.writeFile(fullPath, relPath, config.Files[relPath]); err != nil {
 			return fmt.Errorf("error writing %s: %w", relPath, err)
 		}
 		fileNames = append(fileNames, relPath)
 	}
 
-	// Run docker-compose up -d if enabled
+	// Record how the project was generated
+	if config.Lock != nil {
+		config.Lock.AddFiles(config.Files, config.HoldsSecret)
+		if err := WriteLock(config.ProjectDir, config.Lock); err != nil {
+			return err
+		}
+		fileNames = append(fileNames, LockFile)
+		if len(config.Lock.Secrets) > 0 {
+			fileNames = append(fileNames, SecretsFile)
+		}
+
+		if err := RecordProject(config.Record()); err != nil {
+			ui.Warnf("Could not record the project in the local registry: %v\n", err)
+		}
+	}
+
+	// Run compose up -d if enabled
+	var startErr error
 	if config.AutoStart {
-		if err := startDockerCompose(config.ProjectDir); err != nil {
-			fmt.Printf("\nWARNING: Error starting Docker Compose: %v\n", err)
-			fmt.Println("You can start it manually with: docker-compose up -d")
+		if err := startCompose(ctx, ui, config.Runner, config.ProjectDir); err != nil {
+			ui.Warnf("Error starting Docker Compose: %v\n", err)
+			ui.Warnf("You can start it manually with: %s up -d\n", config.Runner.Name())
+			startErr = &StartError{Err: err}
 		}
 	}
 
 	// Show summary
-	printSuccess(config, fileNames)
+	printSuccess(ui, config, fileNames, skipped)
 
-	return nil
+	return startErr
 }
 
-// startDockerCompose runs docker-compose up -d in the specified directory
-func startDockerCompose(projectDir string) error {
-	fmt.Println("\nStarting Docker services...")
+// StartError is returned by GenerateStack when the files were written but
+// compose up failed
+type StartError struct {
+	Err error
+}
+
+func (e *StartError) Error() string {
+	return "error starting Docker Compose: " + e.Err.Error()
+}
 
-	return exec.Command("sh", "-c", fmt.Sprintf("cd %s && docker-compose up -d", projectDir)).Run()
+func (e *StartError) Unwrap() error {
+	return e.Err
+}
+
+// startCompose runs compose up -d in the specified directory
+func startCompose(ctx context.Context, ui *UI, runner compose.Runner, projectDir string) error {
+	ui.Printf("\nStarting Docker services with %s...\n", runner.Name())
+
+	return runner.Run(ctx, projectDir, "up", "-d")
 }
 
 // printSuccess shows a formatted success message
-func printSuccess(config StackConfig, files []string) {
-	fmt.Println("\n=== Stack Created Successfully ===")
-	fmt.Printf("Name: %s\n", config.Name)
+func printSuccess(ui *UI, config StackConfig, files, skipped []string) {
+	ui.Println("\n=== Stack Created Successfully ===")
+	ui.Printf("Name: %s\n", config.Name)
+	ui.Printf("Project: %s\n", config.ProjectName)
 
 	if config.Description != "" {
-		fmt.Printf("Description: %s\n", config.Description)
+		ui.Printf("Description: %s\n", config.Description)
 	}
 
-	fmt.Printf("Directory: %s\n", config.ProjectDir)
-	fmt.Println("\nGenerated files:")
+	ui.Printf("Directory: %s\n", config.ProjectDir)
+	ui.Println("\nGenerated files:")
 	for _, file := range files {
-		fmt.Printf("  - %s\n", file)
+		ui.Printf("  - %s\n", file)
+	}
+
+	if len(skipped) > 0 {
+		ui.Println("\nKept existing files:")
+		for _, file := range skipped {
+			ui.Printf("  - %s\n", file)
+		}
 	}
 
 	if !config.AutoStart {
-		fmt.Println("\nTo start the stack:")
-		fmt.Printf("  cd %s\n", config.ProjectDir)
-		fmt.Println("  docker-compose up -d")
+		ui.Println("\nTo start the stack:")
+		ui.Printf("  autostack up %s\n", config.ProjectDir)
 	} else {
-		fmt.Println("\nStack is starting...")
+		ui.Println("\nStack is starting...")
+	}
+
+	if len(config.GeneratedSecrets) > 0 {
+		ui.Println("\nGenerated secrets (shown only once, also stored in " + SecretsFile + "):")
+		for _, key := range sortedKeys(config.GeneratedSecrets) {
+			ui.Printf("  %s: %s\n", key, config.GeneratedSecrets[key])
+		}
 	}
 
 	if len(config.Ports) > 0 {
-		fmt.Println("\nAccess URLs:")
+		ui.Println("\nAccess URLs:")
 		for service, port := range config.Ports {
-			fmt.Printf("  %s: http://localhost:%s\n", service, port)
+			ui.Printf("  %s: http://localhost:%s\n", service, port)
 		}
 	}
-	fmt.Println()
+	ui.Println()
+}
+
+// sortedKeys returns the keys of m in sorted order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
 }
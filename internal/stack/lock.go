@@ -0,0 +1,223 @@
+package stack
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Version is the autostack version, set at build time with
+// -ldflags "-X github.com/bait-py/autostack/internal/stack.Version=1.2.3"
+var Version = "dev"
+
+// LockFile records how a project was generated
+const LockFile = ".autostack.lock"
+
+// SecretsFile holds the secret values referenced by the lockfile.
+// It is written with 0600 permissions and must not be committed.
+const SecretsFile = ".autostack.secrets"
+
+// SnapshotDir holds a copy of the stack manifest and templates the project
+// was generated from, so the original version can be rendered again on upgrade
+const SnapshotDir = ".autostack/template"
+
+// lockVersion is the format version of the lockfile
+const lockVersion = 1
+
+// Lock is the content of a project lockfile
+type Lock struct {
+	LockVersion int               `yaml:"lock_version"`
+	Autostack   string            `yaml:"autostack"`
+	Stack       LockStack         `yaml:"stack"`
+	Project     string            `yaml:"project"`
+	Created     time.Time         `yaml:"created"`
+	Env         map[string]string `yaml:"env,omitempty"`
+	Secrets     []string          `yaml:"secrets,omitempty"` // variables whose values live in SecretsFile
+	Ports       map[string]string `yaml:"ports,omitempty"`
+	EnvFile     bool              `yaml:"env_file,omitempty"`     // values are written to .env
+	SecretFiles bool              `yaml:"secret_files,omitempty"` // secrets are written to SecretsDir
+	Files       map[string]string `yaml:"files"`                  // relative path -> sha256 of the generated content, files holding secrets excepted
+
+	secretValues map[string]string // written to SecretsFile, never to the lock
+	template     Stack             // written to SnapshotDir
+}
+
+// LockStack identifies the stack templates a project was generated from
+type LockStack struct {
+	Name    string `yaml:"name"`
+	Source  string `yaml:"source"`
+	Version string `yaml:"version,omitempty"`
+	Hash    string `yaml:"hash"`
+}
+
+// IsSecret reports whether a variable name looks like a secret that must
+// not be shown or stored in clear text
+func IsSecret(name string) bool {
+	lower := strings.ToLower(name)
+	return strings.Contains(lower, "password") || strings.Contains(lower, "secret")
+}
+
+// NewLock creates the lockfile of a project generated from s. Secret values
+// are referenced by name and kept out of the lock.
+func NewLock(s Stack, project string, envValues, portValues map[string]string) *Lock {
+	lock := &Lock{
+		LockVersion: lockVersion,
+		Autostack:   Version,
+		Stack: LockStack{
+			Name:    s.Name,
+			Source:  s.Source,
+			Version: s.Version,
+			Hash:    s.Hash,
+		},
+		Project: project,
+		Created: time.Now().UTC().Truncate(time.Second),
+		Env:     make(map[string]string),
+		Ports:   portValues,
+		Files:   make(map[string]string),
+
+		secretValues: make(map[string]string),
+		template:     s,
+	}
+
+	secret := make(map[string]bool)
+	for _, v := range s.EnvVars {
+		secret[v.VarName] = v.Secret()
+	}
+
+	for key, value := range envValues {
+		if secret[key] {
+			lock.Secrets = append(lock.Secrets, key)
+			lock.secretValues[key] = value
+		} else {
+			lock.Env[key] = value
+		}
+	}
+	sort.Strings(lock.Secrets)
+
+	return lock
+}
+
+// AddFiles records the hash of every generated file. Files for which
+// holdsSecret is true, such as .env or secrets/*, are left out: the lock is
+// committed and the hash of a file that is little more than a password is
+// easily cracked.
+func (lock *Lock) AddFiles(files map[string]string, holdsSecret func(relPath string) bool) {
+	for relPath, content := range files {
+		if holdsSecret(relPath) {
+			continue
+		}
+		lock.Files[relPath] = HashContent([]byte(content))
+	}
+}
+
+// HashContent returns the sha256 of content as written in the lockfile
+func HashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// WriteLock writes the lockfile, the secrets file and the template snapshot
+// into dir
+func WriteLock(dir string, lock *Lock) error {
+	data, err := yaml.Marshal(lock)
+	if err != nil {
+		return err
+	}
+
+	header := "# Generated by autostack. Do not edit: it records how this project was created.\n"
+	if err := os.WriteFile(filepath.Join(dir, LockFile), append([]byte(header), data...), 0644); err != nil {
+		return fmt.Errorf("error writing %s: %w", LockFile, err)
+	}
+
+	if err := writeSnapshot(dir, lock.template); err != nil {
+		return err
+	}
+
+	if len(lock.Secrets) == 0 {
+		return nil
+	}
+
+	var b strings.Builder
+	b.WriteString("# Secret values referenced by " + LockFile + ". Do not commit this file.\n")
+	for _, key := range lock.Secrets {
+		fmt.Fprintf(&b, "%s=%s\n", key, lock.secretValues[key])
+	}
+	if err := os.WriteFile(filepath.Join(dir, SecretsFile), []byte(b.String()), 0600); err != nil {
+		return fmt.Errorf("error writing %s: %w", SecretsFile, err)
+	}
+	return nil
+}
+
+// ReadLock reads the lockfile of the project in dir
+func ReadLock(dir string) (*Lock, error) {
+	data, err := os.ReadFile(filepath.Join(dir, LockFile))
+	if err != nil {
+		return nil, err
+	}
+
+	var lock Lock
+	if err := yaml.Unmarshal(data, &lock); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", filepath.Join(dir, LockFile), err)
+	}
+	return &lock, nil
+}
+
+// ReadSecrets reads the secret values referenced by the lockfile in dir
+func ReadSecrets(dir string) (map[string]string, error) {
+	file, err := os.Open(filepath.Join(dir, SecretsFile))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	secrets := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok {
+			secrets[key] = value
+		}
+	}
+	return secrets, scanner.Err()
+}
+
+// writeSnapshot copies the manifest and templates of s into the project,
+// replacing any previous snapshot
+func writeSnapshot(dir string, s Stack) error {
+	snapshot := filepath.Join(dir, SnapshotDir)
+	if err := os.RemoveAll(snapshot); err != nil {
+		return err
+	}
+
+	files := map[string]string{ManifestFile: s.Manifest}
+	for relPath, content := range s.Files {
+		files[filepath.Join(filesDir, relPath)] = content
+	}
+
+	for relPath, content := range files {
+		fullPath := filepath.Join(snapshot, relPath)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0644); err != nil {
+			return fmt.Errorf("error writing template snapshot: %w", err)
+		}
+	}
+	return nil
+}
+
+// ReadSnapshot loads the stack the project in dir was generated from
+func ReadSnapshot(dir string) (Stack, error) {
+	return LoadStack(os.DirFS(dir), SnapshotDir)
+}
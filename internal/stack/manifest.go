@@ -0,0 +1,270 @@
+package stack
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ManifestFile is the name of the manifest inside a stack directory
+const ManifestFile = "stack.yaml"
+
+// filesDir holds the templates of a stack, relative to its directory
+const filesDir = "files"
+
+//go:embed all:stacks
+var builtinStacks embed.FS
+
+// manifest is the on-disk representation of a stack
+type manifest struct {
+	Name        string            `yaml:"name"`
+	Version     string            `yaml:"version"`
+	Aliases     []string          `yaml:"aliases"`
+	Title       string            `yaml:"title"`
+	Description string            `yaml:"description"`
+	ProjectDir  string            `yaml:"project_dir"`
+	Env         []manifestEnvVar  `yaml:"env"`
+	Ports       []manifestPort    `yaml:"ports"`
+	FixedPorts  map[string]string `yaml:"fixed_ports"`
+	Dirs        []manifestPath    `yaml:"dirs"`
+	Files       []manifestPath    `yaml:"files"`
+	Raw         []string          `yaml:"raw"`
+}
+
+// manifestPath is an entry of dirs or files: a plain path, or a path with
+// the condition under which it is generated
+type manifestPath struct {
+	Path string `yaml:"path"`
+	When string `yaml:"when"`
+}
+
+// UnmarshalYAML accepts a plain string as well as a path and condition
+func (p *manifestPath) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		return node.Decode(&p.Path)
+	}
+	type plain manifestPath
+	return node.Decode((*plain)(p))
+}
+
+type manifestEnvVar struct {
+	Name        string   `yaml:"name"`
+	Description string   `yaml:"description"`
+	Default     string   `yaml:"default"`
+	Kind        string   `yaml:"kind"`
+	Choices     []string `yaml:"choices"`
+	Length      int      `yaml:"length"`
+	Alphabet    string   `yaml:"alphabet"`
+	Generate    *bool    `yaml:"generate"`
+	Shared      bool     `yaml:"shared"`
+	Pattern     string   `yaml:"pattern"`
+	Min         *int     `yaml:"min"`
+	Max         *int     `yaml:"max"`
+	When        string   `yaml:"when"`
+}
+
+type manifestPort struct {
+	Service     string `yaml:"service"`
+	Label       string `yaml:"label"`
+	Description string `yaml:"description"`
+	Default     string `yaml:"default"`
+	Internal    string `yaml:"internal"`
+	When        string `yaml:"when"`
+}
+
+func init() {
+	stacks, err := LoadStacks(builtinStacks, "stacks")
+	if err != nil {
+		panic(fmt.Sprintf("stack: loading built-in stacks: %v", err))
+	}
+	for _, s := range stacks {
+		s.Source = SourceBuiltin
+		Register(s)
+	}
+}
+
+// LoadStacks loads every stack directory found under root
+func LoadStacks(fsys fs.FS, root string) ([]Stack, error) {
+	dirs, err := stackDirs(fsys, root)
+	if err != nil {
+		return nil, err
+	}
+
+	var stacks []Stack
+	for _, dir := range dirs {
+		s, err := LoadStack(fsys, dir)
+		if err != nil {
+			return nil, err
+		}
+		stacks = append(stacks, s)
+	}
+	return stacks, nil
+}
+
+// stackDirs returns the directories under root that hold a manifest
+func stackDirs(fsys fs.FS, root string) ([]string, error) {
+	entries, err := fs.ReadDir(fsys, root)
+	if err != nil {
+		return nil, err
+	}
+
+	var dirs []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := path.Join(root, entry.Name())
+
+		// Directories without a manifest are not stacks
+		if _, err := fs.Stat(fsys, path.Join(dir, ManifestFile)); errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		dirs = append(dirs, dir)
+	}
+	return dirs, nil
+}
+
+// LoadStack loads a single stack from its manifest and template files
+func LoadStack(fsys fs.FS, dir string) (Stack, error) {
+	data, err := fs.ReadFile(fsys, path.Join(dir, ManifestFile))
+	if err != nil {
+		return Stack{}, err
+	}
+
+	var m manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return Stack{}, fmt.Errorf("error parsing %s: %w", path.Join(dir, ManifestFile), err)
+	}
+	if m.Name == "" {
+		return Stack{}, errors.New(path.Join(dir, ManifestFile) + ": name is required")
+	}
+	// Names are looked up in lowercase and double as the compose project name
+	for _, name := range append([]string{m.Name}, m.Aliases...) {
+		if !projectNamePattern.MatchString(name) {
+			return Stack{}, fmt.Errorf("%s: invalid stack name %q: use lowercase letters, digits, '-' and '_', starting with a letter or digit", path.Join(dir, ManifestFile), name)
+		}
+	}
+
+	s := Stack{
+		Name:        m.Name,
+		Version:     m.Version,
+		Aliases:     m.Aliases,
+		Title:       m.Title,
+		Description: m.Description,
+		ProjectDir:  m.ProjectDir,
+		FixedPorts:  m.FixedPorts,
+		Raw:         m.Raw,
+		Files:       make(map[string]string),
+		Manifest:    string(data),
+	}
+	if s.Title == "" {
+		s.Title = s.Name
+	}
+	if s.ProjectDir == "" {
+		s.ProjectDir = s.Name + "-stack"
+	}
+
+	for _, v := range m.Env {
+		kind, err := ParseVarKind(v.Kind)
+		if err != nil {
+			return Stack{}, fmt.Errorf("%s: %s: %w", path.Join(dir, ManifestFile), v.Name, err)
+		}
+		envVar := StackEnvVars{
+			VarName:     v.Name,
+			Description: v.Description,
+			Default:     v.Default,
+			Kind:        kind,
+			Choices:     v.Choices,
+			Length:      v.Length,
+			Alphabet:    v.Alphabet,
+			Generate:    v.Generate,
+			Shared:      v.Shared,
+			Pattern:     v.Pattern,
+			Min:         v.Min,
+			Max:         v.Max,
+			When:        v.When,
+		}
+		if err := envVar.check(); err != nil {
+			return Stack{}, fmt.Errorf("%s: %w", path.Join(dir, ManifestFile), err)
+		}
+		s.EnvVars = append(s.EnvVars, envVar)
+	}
+	for _, p := range m.Ports {
+		s.Ports = append(s.Ports, StackPort{
+			ServiceName: p.Service,
+			Label:       p.Label,
+			Description: p.Description,
+			Default:     p.Default,
+			Internal:    p.Internal,
+			When:        p.When,
+		})
+	}
+	for _, d := range m.Dirs {
+		s.Dirs = append(s.Dirs, d.Path)
+		if d.When != "" {
+			s.Conditions = append(s.Conditions, PathCondition{Pattern: d.Path, When: d.When})
+		}
+	}
+	for _, f := range m.Files {
+		if f.Path == "" || f.When == "" {
+			return Stack{}, fmt.Errorf("%s: files entries need a path and a when", path.Join(dir, ManifestFile))
+		}
+		if _, err := path.Match(f.Path, ""); err != nil {
+			return Stack{}, fmt.Errorf("%s: files %q: %w", path.Join(dir, ManifestFile), f.Path, err)
+		}
+		s.Conditions = append(s.Conditions, PathCondition{Pattern: f.Path, When: f.When})
+	}
+	if err := checkConditions(s.EnvVars, s.Ports, s.Conditions); err != nil {
+		return Stack{}, fmt.Errorf("%s: %w", path.Join(dir, ManifestFile), err)
+	}
+	for _, pattern := range s.Raw {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return Stack{}, fmt.Errorf("%s: raw %q: %w", path.Join(dir, ManifestFile), pattern, err)
+		}
+	}
+
+	// Every file under files/ is kept by its relative path and rendered as a
+	// template unless it matches raw
+	templates := path.Join(dir, filesDir)
+	err = fs.WalkDir(fsys, templates, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		s.Files[p[len(templates)+1:]] = string(content)
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Stack{}, fmt.Errorf("error reading templates of %s: %w", s.Name, err)
+	}
+	s.Hash = hashStack(data, s.Files)
+
+	return s, nil
+}
+
+// hashStack hashes the manifest and every template, so any change to the
+// stack definition changes the hash recorded in lockfiles
+func hashStack(manifest []byte, files map[string]string) string {
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	h := sha256.New()
+	h.Write(manifest)
+	for _, p := range paths {
+		fmt.Fprintf(h, "\x00%s\x00%s", p, files[p])
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
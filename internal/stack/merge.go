@@ -0,0 +1,100 @@
+package stack
+
+import (
+	"slices"
+	"strings"
+)
+
+// Conflict markers written by Merge3
+const (
+	markerOurs   = "<<<<<<< current"
+	markerSep    = "======="
+	markerTheirs = ">>>>>>> template"
+)
+
+// Merge3 merges the changes from base to ours and from base to theirs.
+// Regions changed on both sides in different ways are wrapped in conflict
+// markers, in which case conflict is true.
+func Merge3(base, ours, theirs string) (merged string, conflict bool) {
+	baseLines := splitLines(base)
+	oursLines := splitLines(ours)
+	theirsLines := splitLines(theirs)
+
+	matchOurs := matchLines(baseLines, oursLines)
+	matchTheirs := matchLines(baseLines, theirsLines)
+
+	var b strings.Builder
+	i, j, k := 0, 0, 0
+	for {
+		// Find the next base line kept unchanged on both sides
+		next := i
+		for next < len(baseLines) && (matchOurs[next] < 0 || matchTheirs[next] < 0) {
+			next++
+		}
+
+		nextOurs, nextTheirs := len(oursLines), len(theirsLines)
+		if next < len(baseLines) {
+			nextOurs, nextTheirs = matchOurs[next], matchTheirs[next]
+		}
+
+		if mergeChunk(&b, baseLines[i:next], oursLines[j:nextOurs], theirsLines[k:nextTheirs]) {
+			conflict = true
+		}
+
+		if next == len(baseLines) {
+			break
+		}
+		b.WriteString(baseLines[next])
+		i, j, k = next+1, nextOurs+1, nextTheirs+1
+	}
+
+	return b.String(), conflict
+}
+
+// matchLines maps every line of base to its index in other, or -1 when the
+// line was changed or removed
+func matchLines(base, other []string) []int {
+	match := make([]int, len(base))
+	for i := range match {
+		match[i] = -1
+	}
+	for _, e := range diffLines(base, other) {
+		if e.kind == editEqual {
+			match[e.a] = e.b
+		}
+	}
+	return match
+}
+
+// mergeChunk writes the merge of a region between two stable lines and
+// reports whether it conflicts
+func mergeChunk(b *strings.Builder, base, ours, theirs []string) bool {
+	switch {
+	case slices.Equal(ours, base):
+		writeLines(b, theirs)
+	case slices.Equal(theirs, base), slices.Equal(ours, theirs):
+		writeLines(b, ours)
+	default:
+		b.WriteString(markerOurs + "\n")
+		writeLines(b, ours)
+		ensureNewline(b)
+		b.WriteString(markerSep + "\n")
+		writeLines(b, theirs)
+		ensureNewline(b)
+		b.WriteString(markerTheirs + "\n")
+		return true
+	}
+	return false
+}
+
+func writeLines(b *strings.Builder, lines []string) {
+	for _, line := range lines {
+		b.WriteString(line)
+	}
+}
+
+func ensureNewline(b *strings.Builder) {
+	if b.Len() > 0 && !strings.HasSuffix(b.String(), "\n") {
+		b.WriteString("\n")
+	}
+}
@@ -0,0 +1,92 @@
+package stack
+
+import "testing"
+
+func TestMerge3(t *testing.T) {
+	tests := []struct {
+		name         string
+		base         string
+		ours         string
+		theirs       string
+		want         string
+		wantConflict bool
+	}{
+		{
+			name:   "unchanged",
+			base:   "a\nb\nc\n",
+			ours:   "a\nb\nc\n",
+			theirs: "a\nb\nc\n",
+			want:   "a\nb\nc\n",
+		},
+		{
+			name:   "only ours changed",
+			base:   "a\nb\nc\n",
+			ours:   "a\nB\nc\n",
+			theirs: "a\nb\nc\n",
+			want:   "a\nB\nc\n",
+		},
+		{
+			name:   "only theirs changed",
+			base:   "a\nb\nc\n",
+			ours:   "a\nb\nc\n",
+			theirs: "a\nb\nC\n",
+			want:   "a\nb\nC\n",
+		},
+		{
+			name:   "clean merge of separate changes",
+			base:   "a\nb\nc\nd\ne\n",
+			ours:   "A\nb\nc\nd\ne\n",
+			theirs: "a\nb\nc\nd\nE\n",
+			want:   "A\nb\nc\nd\nE\n",
+		},
+		{
+			name:   "lines added on both sides in different places",
+			base:   "a\nb\nc\n",
+			ours:   "a\nours\nb\nc\n",
+			theirs: "a\nb\nc\ntheirs\n",
+			want:   "a\nours\nb\nc\ntheirs\n",
+		},
+		{
+			name:   "same change on both sides",
+			base:   "a\nb\nc\n",
+			ours:   "a\nx\nc\n",
+			theirs: "a\nx\nc\n",
+			want:   "a\nx\nc\n",
+		},
+		{
+			name:   "line removed by theirs",
+			base:   "a\nb\nc\n",
+			ours:   "a\nb\nc\n",
+			theirs: "a\nc\n",
+			want:   "a\nc\n",
+		},
+		{
+			name:         "conflict",
+			base:         "a\nb\nc\n",
+			ours:         "a\nours\nc\n",
+			theirs:       "a\ntheirs\nc\n",
+			want:         "a\n<<<<<<< current\nours\n=======\ntheirs\n>>>>>>> template\nc\n",
+			wantConflict: true,
+		},
+		{
+			name:         "conflict without final newline",
+			base:         "a\nb",
+			ours:         "a\nours",
+			theirs:       "a\ntheirs",
+			want:         "a\n<<<<<<< current\nours\n=======\ntheirs\n>>>>>>> template\n",
+			wantConflict: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conflict := Merge3(tt.base, tt.ours, tt.theirs)
+			if got != tt.want {
+				t.Errorf("Merge3() = %q, want %q", got, tt.want)
+			}
+			if conflict != tt.wantConflict {
+				t.Errorf("Merge3() conflict = %v, want %v", conflict, tt.wantConflict)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package stack
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Port range accepted for host ports
+const (
+	minPort               = 1
+	maxPort               = 65535
+	firstUnprivilegedPort = 1024
+)
+
+// PortOptions control how host ports are checked
+type PortOptions struct {
+	AutoPorts       bool   // pick the next free port instead of failing on a conflict
+	AllowPrivileged bool   // accept ports below 1024
+	ProjectDir      string // project being generated, its own registry record is ignored
+}
+
+// PortChange is a port moved by --auto-ports
+type PortChange struct {
+	Service string
+	From    string
+	To      string
+	Reason  string
+}
+
+// ValidatePort checks that value is a usable host port number
+func ValidatePort(value string, allowPrivileged bool) (int, error) {
+	port, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a port number", value)
+	}
+	if port < minPort || port > maxPort {
+		return 0, fmt.Errorf("port %d is out of range %d-%d", port, minPort, maxPort)
+	}
+	if port < firstUnprivilegedPort && !allowPrivileged {
+		return 0, fmt.Errorf("port %d is privileged, use a port from %d or --allow-privileged-ports", port, firstUnprivilegedPort)
+	}
+	return port, nil
+}
+
+// PortAvailable reports whether the port can be bound on the host
+func PortAvailable(port int) bool {
+	listener, err := net.Listen("tcp", ":"+strconv.Itoa(port))
+	if err != nil {
+		return false
+	}
+	listener.Close()
+	return true
+}
+
+// ClaimedPorts returns the host ports used by other generated projects,
+// mapped to the name of the project using them
+func ClaimedPorts(projectDir string) (map[int]string, error) {
+	records, err := LoadProjects()
+	if err != nil {
+		return nil, err
+	}
+
+	own, _ := filepath.Abs(projectDir)
+	claimed := make(map[int]string)
+	for _, r := range records {
+		if r.Path == own || !r.Exists() {
+			continue
+		}
+		for _, value := range r.Ports {
+			if port, err := strconv.Atoi(value); err == nil {
+				claimed[port] = r.Name
+			}
+		}
+	}
+	return claimed, nil
+}
+
+// PortChecker finds the host ports that are in use on the host, claimed by
+// another generated project or already chosen for the stack
+type PortChecker struct {
+	claimed map[int]string // port -> project using it
+	used    map[int]string // port -> service of this stack
+}
+
+// NewPortChecker creates a checker for the project generated in projectDir
+func NewPortChecker(projectDir string) (*PortChecker, error) {
+	claimed, err := ClaimedPorts(projectDir)
+	if err != nil {
+		return nil, err
+	}
+	return &PortChecker{claimed: claimed, used: make(map[int]string)}, nil
+}
+
+// Conflict returns why port cannot be used, or "" when it is free
+func (c *PortChecker) Conflict(port int) string {
+	if service, ok := c.used[port]; ok {
+		return "also chosen for " + service
+	}
+	if project, ok := c.claimed[port]; ok {
+		return "used by project " + project
+	}
+	if !PortAvailable(port) {
+		return "in use on this host"
+	}
+	return ""
+}
+
+// Use records port as chosen for service
+func (c *PortChecker) Use(port int, service string) {
+	c.used[port] = service
+}
+
+// NextFree returns the first port after port without a conflict
+func (c *PortChecker) NextFree(port int) (int, error) {
+	return nextFreePort(port, c.Conflict)
+}
+
+// CheckPorts validates the chosen host ports. Ports that are already in use
+// on the host, claimed by another generated project or chosen twice are
+// conflicts: with AutoPorts the next free port is picked and reported,
+// otherwise an error lists every problem.
+func CheckPorts(ports []StackPort, values map[string]string, opts PortOptions) ([]PortChange, error) {
+	checker, err := NewPortChecker(opts.ProjectDir)
+	if err != nil {
+		return nil, err
+	}
+	conflict := checker.Conflict
+
+	var changes []PortChange
+	var problems []string
+	for _, p := range ports {
+		value, ok := values[p.ServiceName]
+		if !ok {
+			continue
+		}
+
+		port, err := ValidatePort(value, opts.AllowPrivileged)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", p.ServiceName, err))
+			continue
+		}
+
+		if reason := conflict(port); reason != "" {
+			if !opts.AutoPorts {
+				problems = append(problems, fmt.Sprintf("%s: port %d is %s", p.ServiceName, port, reason))
+				continue
+			}
+
+			next, err := nextFreePort(port, conflict)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", p.ServiceName, err))
+				continue
+			}
+			changes = append(changes, PortChange{
+				Service: p.ServiceName,
+				From:    value,
+				To:      strconv.Itoa(next),
+				Reason:  reason,
+			})
+			port = next
+			values[p.ServiceName] = strconv.Itoa(next)
+		}
+
+		checker.Use(port, p.ServiceName)
+	}
+
+	if len(problems) > 0 {
+		msg := "invalid port configuration:\n  - " + strings.Join(problems, "\n  - ")
+		if !opts.AutoPorts {
+			msg += "\nchoose other ports or use --auto-ports to pick free ones"
+		}
+		return nil, errors.New(msg)
+	}
+	return changes, nil
+}
+
+// nextFreePort returns the first port after port without a conflict
+func nextFreePort(port int, conflict func(int) string) (int, error) {
+	for candidate := port + 1; candidate <= maxPort; candidate++ {
+		if conflict(candidate) == "" {
+			return candidate, nil
+		}
+	}
+	return 0, fmt.Errorf("no free port found after %d", port)
+}
+
+// PrintPortChanges reports the ports moved by --auto-ports
+func PrintPortChanges(ui *UI, changes []PortChange) {
+	if len(changes) == 0 {
+		return
+	}
+
+	ui.Println("\nPorts changed to avoid conflicts:")
+	for _, c := range changes {
+		ui.Printf("  %s: %s -> %s (%s was %s)\n", c.Service, c.From, c.To, c.From, c.Reason)
+	}
+}
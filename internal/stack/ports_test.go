@@ -0,0 +1,225 @@
+package stack
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidatePort(t *testing.T) {
+	tests := []struct {
+		value           string
+		allowPrivileged bool
+		want            int
+		wantErr         bool
+	}{
+		{value: "8080", want: 8080},
+		{value: " 8080 ", want: 8080},
+		{value: "1024", want: 1024},
+		{value: "65535", want: 65535},
+		{value: "80", wantErr: true},
+		{value: "80", allowPrivileged: true, want: 80},
+		{value: "1", allowPrivileged: true, want: 1},
+		{value: "0", allowPrivileged: true, wantErr: true},
+		{value: "65536", wantErr: true},
+		{value: "-1", wantErr: true},
+		{value: "", wantErr: true},
+		{value: "http", wantErr: true},
+		{value: "80:80", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ValidatePort(tt.value, tt.allowPrivileged)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ValidatePort(%q, %v) = %d, want an error", tt.value, tt.allowPrivileged, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ValidatePort(%q, %v): %v", tt.value, tt.allowPrivileged, err)
+			}
+			if got != tt.want {
+				t.Errorf("ValidatePort(%q, %v) = %d, want %d", tt.value, tt.allowPrivileged, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextFreePort(t *testing.T) {
+	tests := []struct {
+		name    string
+		port    int
+		taken   []int
+		want    int
+		wantErr bool
+	}{
+		{name: "next is free", port: 8080, want: 8081},
+		{name: "skips taken ports", port: 8080, taken: []int{8081, 8082}, want: 8083},
+		{name: "ignores the port itself", port: 8080, taken: []int{8080}, want: 8081},
+		{name: "last port", port: 65534, want: 65535},
+		{name: "none left", port: 65534, taken: []int{65535}, wantErr: true},
+		{name: "after the last port", port: 65535, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conflict := func(port int) string {
+				for _, taken := range tt.taken {
+					if port == taken {
+						return "taken"
+					}
+				}
+				return ""
+			}
+
+			got, err := nextFreePort(tt.port, conflict)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("nextFreePort(%d) = %d, want an error", tt.port, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("nextFreePort(%d): %v", tt.port, err)
+			}
+			if got != tt.want {
+				t.Errorf("nextFreePort(%d) = %d, want %d", tt.port, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckPorts(t *testing.T) {
+	t.Setenv("AUTOSTACK_STATE_DIR", t.TempDir())
+
+	// A port in use on this host, and one claimed by another project
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	inUse := listener.Addr().(*net.TCPAddr).Port
+	claimed := freePort(t)
+
+	other := t.TempDir()
+	if err := SaveProjects([]ProjectRecord{{
+		Name:    "other",
+		Path:    other,
+		Stack:   "mariadb",
+		Ports:   map[string]string{"mariadb": strconv.Itoa(claimed)},
+		Created: time.Now(),
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	free := freePort(t)
+	for free == claimed {
+		free = freePort(t)
+	}
+
+	ports := []StackPort{{ServiceName: "web"}, {ServiceName: "db"}}
+	tests := []struct {
+		name       string
+		values     map[string]string
+		opts       PortOptions
+		wantErr    string
+		wantReason map[string]string // service -> reason of the change
+	}{
+		{
+			name:   "free port",
+			values: map[string]string{"web": strconv.Itoa(free)},
+		},
+		{
+			name:    "claimed by another project",
+			values:  map[string]string{"web": strconv.Itoa(claimed)},
+			wantErr: "used by project other",
+		},
+		{
+			name:       "claimed by another project with auto ports",
+			values:     map[string]string{"web": strconv.Itoa(claimed)},
+			opts:       PortOptions{AutoPorts: true},
+			wantReason: map[string]string{"web": "used by project other"},
+		},
+		{
+			name:   "claimed by the project itself",
+			values: map[string]string{"web": strconv.Itoa(claimed)},
+			opts:   PortOptions{ProjectDir: other},
+		},
+		{
+			name:    "in use on the host",
+			values:  map[string]string{"web": strconv.Itoa(inUse)},
+			wantErr: "in use on this host",
+		},
+		{
+			name:       "in use on the host with auto ports",
+			values:     map[string]string{"web": strconv.Itoa(inUse)},
+			opts:       PortOptions{AutoPorts: true},
+			wantReason: map[string]string{"web": "in use on this host"},
+		},
+		{
+			name:    "chosen twice",
+			values:  map[string]string{"web": strconv.Itoa(free), "db": strconv.Itoa(free)},
+			wantErr: "also chosen for web",
+		},
+		{
+			name:       "chosen twice with auto ports",
+			values:     map[string]string{"web": strconv.Itoa(free), "db": strconv.Itoa(free)},
+			opts:       PortOptions{AutoPorts: true},
+			wantReason: map[string]string{"db": "also chosen for web"},
+		},
+		{
+			name:    "privileged",
+			values:  map[string]string{"web": "80"},
+			opts:    PortOptions{AutoPorts: true},
+			wantErr: "port 80 is privileged",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values := make(map[string]string)
+			for service, value := range tt.values {
+				values[service] = value
+			}
+
+			changes, err := CheckPorts(ports, values, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("CheckPorts(%v) error = %v, want one containing %q", tt.values, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CheckPorts(%v): %v", tt.values, err)
+			}
+
+			if len(changes) != len(tt.wantReason) {
+				t.Fatalf("CheckPorts(%v) changes = %+v, want %d", tt.values, changes, len(tt.wantReason))
+			}
+			for _, c := range changes {
+				if c.Reason != tt.wantReason[c.Service] {
+					t.Errorf("%s moved because %q, want %q", c.Service, c.Reason, tt.wantReason[c.Service])
+				}
+				from, _ := strconv.Atoi(c.From)
+				to, _ := strconv.Atoi(c.To)
+				if c.From != tt.values[c.Service] || to <= from || values[c.Service] != c.To {
+					t.Errorf("%s moved from %s to %s (value %s), want a later port than %s", c.Service, c.From, c.To, values[c.Service], tt.values[c.Service])
+				}
+			}
+		})
+	}
+}
+
+// freePort returns a port that nothing listens on
+func freePort(t *testing.T) int {
+	t.Helper()
+	listener, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
@@ -0,0 +1,71 @@
+package stack
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// Project is a directory generated by autostack
+type Project struct {
+	Dir  string
+	Lock *Lock
+}
+
+// FindProject locates a generated project by directory or by the project
+// name recorded in its lockfile. Names are looked up in the subdirectories
+// of the current directory, then in the local project registry. A name
+// shared by several projects is an error rather than a guess.
+func FindProject(ref string) (Project, error) {
+	if ref == "" {
+		ref = "."
+	}
+
+	lock, err := ReadLock(ref)
+	if err == nil {
+		return Project{Dir: ref, Lock: lock}, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return Project{}, err
+	}
+
+	entries, err := os.ReadDir(".")
+	if err != nil {
+		return Project{}, err
+	}
+	var matches []Project
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		lock, err := ReadLock(entry.Name())
+		if err != nil {
+			continue
+		}
+		if lock.Project == ref {
+			matches = append(matches, Project{Dir: entry.Name(), Lock: lock})
+		}
+	}
+	switch len(matches) {
+	case 0:
+	case 1:
+		return matches[0], nil
+	default:
+		return Project{}, ambiguousProject(len(matches), ref)
+	}
+
+	record, err := FindRecord(ref)
+	if errors.Is(err, errAmbiguousProject) {
+		return Project{}, err
+	}
+	if err == nil {
+		lock, err := ReadLock(record.Path)
+		if err != nil {
+			return Project{}, fmt.Errorf("project %s is registered at %s but cannot be read: %w", ref, record.Path, err)
+		}
+		return Project{Dir: record.Path, Lock: lock}, nil
+	}
+
+	return Project{}, fmt.Errorf("project not found: %s is neither a generated directory nor a project name", ref)
+}
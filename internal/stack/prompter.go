@@ -0,0 +1,161 @@
+package stack
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// errNoInput is returned when input ends before a valid answer was given
+var errNoInput = errors.New("no more input")
+
+// prompter reads answers to interactive questions
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+	fd  int // terminal used for hidden input, -1 when input is not a terminal
+}
+
+// newPrompter creates a prompter reading from in and writing to out
+func newPrompter(in io.Reader, out io.Writer) *prompter {
+	p := &prompter{in: bufio.NewReader(in), out: out, fd: -1}
+	if f, ok := in.(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		p.fd = int(f.Fd())
+	}
+	return p
+}
+
+// readLine reads one answer. Input ending without a newline still counts as
+// an answer, errNoInput is only returned when nothing was left to read.
+func (p *prompter) readLine() (string, error) {
+	line, err := p.in.ReadString('\n')
+	if err != nil && line == "" {
+		return "", errNoInput
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// readHidden reads one answer without echoing it when input is a terminal
+func (p *prompter) readHidden() (string, error) {
+	if p.fd < 0 {
+		return p.readLine()
+	}
+	data, err := term.ReadPassword(p.fd)
+	fmt.Fprintln(p.out)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// ask reads answers until validate accepts one. An empty answer is passed
+// to validate as is, so it decides how defaults are handled. When input ends
+// the empty answer is tried one last time.
+func (p *prompter) ask(label string, hidden bool, validate func(string) error) (string, error) {
+	for {
+		fmt.Fprint(p.out, label)
+
+		var answer string
+		var err error
+		if hidden {
+			answer, err = p.readHidden()
+		} else {
+			answer, err = p.readLine()
+		}
+		if errors.Is(err, errNoInput) {
+			// Nothing left to read: accept the default if there is one
+			if validate("") == nil {
+				return "", nil
+			}
+			return "", err
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if err := validate(answer); err != nil {
+			fmt.Fprintf(p.out, "      Invalid value: %v\n", err)
+			continue
+		}
+		return answer, nil
+	}
+}
+
+// choose shows a numbered list and returns the chosen item, selected by
+// number or by value. An empty answer selects def.
+func (p *prompter) choose(choices []string, def string) (string, error) {
+	defIndex := 0
+	for i, choice := range choices {
+		marker := ""
+		if choice == def {
+			defIndex = i + 1
+			marker = " (default)"
+		}
+		fmt.Fprintf(p.out, "      %d) %s%s\n", i+1, choice, marker)
+	}
+
+	label := "      Choose: "
+	if defIndex > 0 {
+		label = fmt.Sprintf("      Choose [%d]: ", defIndex)
+	}
+
+	var chosen string
+	_, err := p.ask(label, false, func(answer string) error {
+		if answer == "" {
+			if defIndex == 0 {
+				return errors.New("choose one of the options")
+			}
+			chosen = def
+			return nil
+		}
+		if n, err := strconv.Atoi(answer); err == nil {
+			if n < 1 || n > len(choices) {
+				return fmt.Errorf("choose a number from 1 to %d", len(choices))
+			}
+			chosen = choices[n-1]
+			return nil
+		}
+		for _, choice := range choices {
+			if answer == choice {
+				chosen = choice
+				return nil
+			}
+		}
+		return fmt.Errorf("%q is not one of the options", answer)
+	})
+	return chosen, err
+}
+
+// confirm asks a yes/no question. An empty answer, or the end of input,
+// selects def.
+func (p *prompter) confirm(question string, def bool) bool {
+	hint := "(y/N)"
+	if def {
+		hint = "(Y/n)"
+	}
+
+	var result bool
+	_, err := p.ask(fmt.Sprintf("%s %s: ", question, hint), false, func(answer string) error {
+		switch strings.ToLower(answer) {
+		case "":
+			result = def
+		case "y", "yes":
+			result = true
+		case "n", "no":
+			result = false
+		default:
+			return errors.New("answer y or n")
+		}
+		return nil
+	})
+	if err != nil {
+		return def
+	}
+	return result
+}
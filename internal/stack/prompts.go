@@ -1,9 +1,8 @@
 package stack
 
 import (
-	"bufio"
 	"fmt"
-	"os"
+	"strconv"
 	"strings"
 )
 
@@ -13,134 +12,227 @@ type StackEnvVars struct {
 	Description string
 	Default     string
 	Value       string
+	Kind        VarKind  // type of the value, KindString when empty
+	Choices     []string // allowed values for KindEnum
+	Length      int      // length of generated secrets
+	Alphabet    string   // characters of generated secrets
+	Generate    *bool    // whether a secret without default is generated, true when nil
+	Shared      bool     // secret file readable by every user, for services not running as root
+	Pattern     string   // regular expression the value must match
+	Min         *int     // smallest value of KindInt
+	Max         *int     // largest value of KindInt
+	When        string   // condition on earlier variables, asked only when true
 }
 
 // StackPort defines a configurable port for a service
 type StackPort struct {
 	ServiceName string
+	Label       string // Name shown in the access URLs summary
 	Description string
 	Default     string
 	HostPort    string // The port on the host machine
 	Internal    string // The internal container port (usually fixed)
+	When        string // condition on variables, used only when true
 }
 
 // PromptAutoStart asks the user if they want to auto-start the stack
-func PromptAutoStart() bool {
-	reader := bufio.NewReader(os.Stdin)
-	fmt.Print("\nAuto-start the stack after creation? (Y/n): ")
-
-	response, _ := reader.ReadString('\n')
-	response = strings.TrimSpace(strings.ToLower(response))
-
-	// Default is "yes"
-	if response == "" || response == "y" || response == "yes" {
-		return true
-	}
-	return false
+func PromptAutoStart(ui *UI) bool {
+	return ui.prompt().confirm("\nAuto-start the stack after creation?", true)
 }
 
-// PromptEnvVars prompts the user for environment variable values
-func PromptEnvVars(vars []StackEnvVars) map[string]string {
-	if len(vars) == 0 {
+// PromptEnvVars prompts the user for the variables without a value, in
+// order, and stores the answers in values. Variables whose condition is
+// false given the earlier answers are skipped. Invalid answers are asked
+// again, secrets are read without echo on a terminal, enums are chosen from
+// a list and bools are yes/no questions. Generated secrets left empty are
+// omitted so the caller generates them.
+func PromptEnvVars(ui *UI, vars []StackEnvVars, values map[string]string) error {
+	var pending []StackEnvVars
+	for _, v := range vars {
+		if _, ok := values[v.VarName]; !ok {
+			pending = append(pending, v)
+		}
+	}
+	if len(pending) == 0 {
 		return nil
 	}
 
-	fmt.Println("\n=== Environment Variables Configuration ===")
-	fmt.Println("Press Enter to use default values")
-	fmt.Println(strings.Repeat("-", 60))
+	fmt.Fprintln(ui.Out, "\n=== Environment Variables Configuration ===")
+	fmt.Fprintln(ui.Out, "Press Enter to use default values")
+	fmt.Fprintln(ui.Out, strings.Repeat("-", 60))
 
-	reader := bufio.NewReader(os.Stdin)
-	result := make(map[string]string)
+	p := ui.prompt()
+	asked := 0
+
+	for i, v := range pending {
+		if !v.Active(values) {
+			continue
+		}
 
-	for i, v := range vars {
-		fmt.Printf("\n[%d/%d] %s\n", i+1, len(vars), v.Description)
-		fmt.Printf("      Default: %s\n", v.Default)
-		fmt.Printf("      Enter value: ")
+		// Later questions may be disabled by this answer, count those still active
+		total := asked
+		for _, next := range pending[i:] {
+			if next.Active(values) {
+				total++
+			}
+		}
+		asked++
+		fmt.Fprintf(ui.Out, "\n[%d/%d] %s\n", asked, total, v.Description)
+
+		switch v.Kind {
+		case KindEnum:
+			value, err := p.choose(v.Choices, v.Default)
+			if err != nil {
+				return fmt.Errorf("%s: %w", v.VarName, err)
+			}
+			values[v.VarName] = value
 
-		value, _ := reader.ReadString('\n')
-		value = strings.TrimSpace(value)
+		case KindBool:
+			def, _ := strconv.ParseBool(v.Default)
+			values[v.VarName] = strconv.FormatBool(p.confirm("      Enable", def))
 
-		if value == "" {
-			result[v.VarName] = v.Default
-		} else {
-			result[v.VarName] = value
+		default:
+			if v.Generated() {
+				fmt.Fprintln(ui.Out, "      Default: randomly generated")
+			} else {
+				fmt.Fprintf(ui.Out, "      Default: %s\n", v.Default)
+			}
+			if hint := v.Hint(); hint != "" {
+				fmt.Fprintf(ui.Out, "      Format: %s\n", hint)
+			}
+
+			value, err := p.ask("      Enter value: ", v.Secret(), func(answer string) error {
+				if answer == "" {
+					if v.Generated() {
+						return nil
+					}
+					return v.Validate(v.Default)
+				}
+				return v.Validate(answer)
+			})
+			if err != nil {
+				return fmt.Errorf("%s: %w", v.VarName, err)
+			}
+			if value != "" {
+				values[v.VarName] = value
+			} else if !v.Generated() {
+				values[v.VarName] = v.Default
+			}
 		}
 	}
 
-	fmt.Println(strings.Repeat("-", 60))
-	return result
+	fmt.Fprintln(ui.Out, strings.Repeat("-", 60))
+	return nil
 }
 
-// PromptPorts prompts the user for port configurations
-func PromptPorts(ports []StackPort) map[string]string {
+// PromptPorts prompts the user for port configurations, asking again until
+// each port is valid. With a checker, ports that conflict are asked again
+// too and every chosen port is recorded in it.
+func PromptPorts(ui *UI, ports []StackPort, allowPrivileged bool, checker *PortChecker) (map[string]string, error) {
 	if len(ports) == 0 {
-		return nil
+		return nil, nil
 	}
 
-	fmt.Println("\n=== Port Configuration ===")
-	fmt.Println("Press Enter to use default values")
-	fmt.Println(strings.Repeat("-", 60))
+	fmt.Fprintln(ui.Out, "\n=== Port Configuration ===")
+	fmt.Fprintln(ui.Out, "Press Enter to use default values")
+	fmt.Fprintln(ui.Out, strings.Repeat("-", 60))
 
-	reader := bufio.NewReader(os.Stdin)
+	p := ui.prompt()
 	result := make(map[string]string)
 
-	for i, p := range ports {
-		fmt.Printf("\n[%d/%d] %s\n", i+1, len(ports), p.Description)
-		fmt.Printf("      Default: %s\n", p.Default)
-		fmt.Printf("      Enter port: ")
+	for i, port := range ports {
+		fmt.Fprintf(ui.Out, "\n[%d/%d] %s\n", i+1, len(ports), port.Description)
+		fmt.Fprintf(ui.Out, "      Default: %s%s\n", port.Default, defaultPortNote(port.Default, allowPrivileged, checker))
 
-		value, _ := reader.ReadString('\n')
-		value = strings.TrimSpace(value)
+		value, err := p.ask("      Enter port: ", false, func(answer string) error {
+			if answer == "" {
+				answer = port.Default
+			}
+			n, err := ValidatePort(answer, allowPrivileged)
+			if err != nil {
+				return err
+			}
+			if checker != nil {
+				if reason := checker.Conflict(n); reason != "" {
+					return fmt.Errorf("port %d is %s", n, reason)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("port %s: %w", port.ServiceName, err)
+		}
 
 		if value == "" {
-			result[p.ServiceName] = p.Default
-		} else {
-			result[p.ServiceName] = value
+			value = port.Default
+		}
+		result[port.ServiceName] = value
+		if checker != nil {
+			n, _ := ValidatePort(value, allowPrivileged)
+			checker.Use(n, port.ServiceName)
 		}
 	}
 
-	fmt.Println(strings.Repeat("-", 60))
-	return result
+	fmt.Fprintln(ui.Out, strings.Repeat("-", 60))
+	return result, nil
+}
+
+// defaultPortNote explains why the default port cannot be used and
+// suggests a free one, empty when the default is fine
+func defaultPortNote(def string, allowPrivileged bool, checker *PortChecker) string {
+	if checker == nil {
+		return ""
+	}
+	port, err := ValidatePort(def, allowPrivileged)
+	if err != nil {
+		return ""
+	}
+	reason := checker.Conflict(port)
+	if reason == "" {
+		return ""
+	}
+	if next, err := checker.NextFree(port); err == nil {
+		return fmt.Sprintf(" (%s, %d is free)", reason, next)
+	}
+	return fmt.Sprintf(" (%s)", reason)
 }
 
 // ConfirmConfiguration shows the configuration and asks for confirmation
-func ConfirmConfiguration(envVars map[string]string, ports map[string]string) bool {
+func ConfirmConfiguration(ui *UI, vars []StackEnvVars, envVars map[string]string, ports map[string]string) bool {
 	hasConfig := len(envVars) > 0 || len(ports) > 0
 	if !hasConfig {
 		return true
 	}
 
-	fmt.Println("\n=== Configuration Summary ===")
+	fmt.Fprintln(ui.Out, "\n=== Configuration Summary ===")
 
 	if len(envVars) > 0 {
-		fmt.Println("\nEnvironment Variables:")
-		for key, value := range envVars {
+		fmt.Fprintln(ui.Out, "\nEnvironment Variables:")
+		for _, v := range vars {
+			key, value := v.VarName, envVars[v.VarName]
+			if _, ok := envVars[key]; !ok {
+				continue // disabled by its condition
+			}
+
 			// Partially hide passwords
 			displayValue := value
-			if strings.Contains(strings.ToLower(key), "password") ||
-				strings.Contains(strings.ToLower(key), "secret") {
+			if v.Secret() {
 				if len(value) > 4 {
 					displayValue = value[:2] + "****" + value[len(value)-2:]
 				} else {
 					displayValue = "****"
 				}
 			}
-			fmt.Printf("      %s: %s\n", key, displayValue)
+			fmt.Fprintf(ui.Out, "      %s: %s\n", key, displayValue)
 		}
 	}
 
 	if len(ports) > 0 {
-		fmt.Println("\nPorts:")
+		fmt.Fprintln(ui.Out, "\nPorts:")
 		for service, port := range ports {
-			fmt.Printf("  %s: %s\n", service, port)
+			fmt.Fprintf(ui.Out, "  %s: %s\n", service, port)
 		}
 	}
 
-	reader := bufio.NewReader(os.Stdin)
-	fmt.Print("\nConfirm configuration? (Y/n): ")
-
-	response, _ := reader.ReadString('\n')
-	response = strings.TrimSpace(strings.ToLower(response))
-
-	return response == "" || response == "y" || response == "yes"
+	return ui.prompt().confirm("\nConfirm configuration?", true)
 }
@@ -0,0 +1,134 @@
+package stack
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Stack describes a stack that can be created with autostack.
+// Every stack registers itself once and all commands read from the registry.
+type Stack struct {
+	Name        string            // name used on the command line
+	Aliases     []string          // alternative names accepted by create
+	Title       string            // display name used in the summary
+	Description string            // stack description
+	ProjectDir  string            // default directory to generate into
+	EnvVars     []StackEnvVars    // configurable environment variables
+	Ports       []StackPort       // configurable ports
+	FixedPorts  map[string]string // service -> port for non-configurable ports
+	Files       map[string]string // relative path -> template content
+	Dirs        []string          // directories to create
+	Raw         []string          // patterns of files copied without rendering
+	Conditions  []PathCondition   // files and directories generated only when enabled
+	Source      string            // where the stack was loaded from
+	Version     string            // template version declared by the manifest
+	Hash        string            // hash of the manifest and templates
+	Manifest    string            // raw manifest, snapshotted into generated projects
+}
+
+var registry = map[string]*Stack{}
+
+// Register adds a stack to the registry. It panics if the name or one of
+// its aliases is already taken, since that is always a programming error.
+func Register(s Stack) {
+	for _, key := range append([]string{s.Name}, s.Aliases...) {
+		if _, exists := registry[key]; exists {
+			panic(fmt.Sprintf("stack: %q registered twice", key))
+		}
+	}
+
+	add(s)
+}
+
+// Replace adds a stack to the registry, taking the place of any stack
+// already registered under the same name. Aliases of the replaced stack
+// are dropped and the new stack's aliases take precedence.
+func Replace(s Stack) {
+	if old, ok := registry[s.Name]; ok && old.Name == s.Name {
+		for key, entry := range registry {
+			if entry == old {
+				delete(registry, key)
+			}
+		}
+	}
+	add(s)
+}
+
+func add(s Stack) {
+	entry := s
+	for _, key := range append([]string{s.Name}, s.Aliases...) {
+		old, ok := registry[key]
+		switch {
+		case ok && key != s.Name && old.Name == key:
+			// Names always win over aliases, an alias never hides a stack
+			entry.Aliases = removeString(entry.Aliases, key)
+			continue
+		case ok && old.Name != key:
+			// A key taken from another stack is no longer one of its aliases
+			old.Aliases = removeString(old.Aliases, key)
+		}
+		registry[key] = &entry
+	}
+}
+
+func removeString(list []string, value string) []string {
+	var result []string
+	for _, item := range list {
+		if item != value {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// Lookup finds a stack by name or alias
+func Lookup(name string) (Stack, bool) {
+	s, ok := registry[strings.ToLower(name)]
+	if !ok {
+		return Stack{}, false
+	}
+	return *s, true
+}
+
+// Stacks returns all registered stacks sorted by name
+func Stacks() []Stack {
+	var stacks []Stack
+	for key, s := range registry {
+		if key == s.Name {
+			stacks = append(stacks, *s)
+		}
+	}
+	sort.Slice(stacks, func(i, j int) bool {
+		return stacks[i].Name < stacks[j].Name
+	})
+	return stacks
+}
+
+// Config builds a StackConfig from the stack definition.
+// Files are copied so the templates of the registered stack stay untouched.
+func (s Stack) Config() StackConfig {
+	files := make(map[string]string, len(s.Files))
+	for path, content := range s.Files {
+		files[path] = content
+	}
+
+	ports := make(map[string]string)
+	for service, port := range s.FixedPorts {
+		ports[service] = port
+	}
+
+	return StackConfig{
+		Name:           s.Title,
+		ProjectName:    s.Name,
+		Description:    s.Description,
+		ProjectDir:     s.ProjectDir,
+		Ports:          ports,
+		Dirs:           append([]string(nil), s.Dirs...),
+		Files:          files,
+		Raw:            s.Raw,
+		Conditions:     s.Conditions,
+		EnvVars:        s.EnvVars,
+		ConfigurePorts: s.Ports,
+	}
+}
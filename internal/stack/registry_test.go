@@ -0,0 +1,96 @@
+package stack
+
+import (
+	"reflect"
+	"testing"
+)
+
+// useRegistry replaces the registry with one holding only stacks for the
+// duration of the test
+func useRegistry(t *testing.T, stacks ...Stack) {
+	t.Helper()
+	saved := registry
+	registry = map[string]*Stack{}
+	t.Cleanup(func() { registry = saved })
+	for _, s := range stacks {
+		Register(s)
+	}
+}
+
+func TestReplace(t *testing.T) {
+	tests := []struct {
+		name        string
+		registered  []Stack
+		replacement Stack
+		want        map[string]string   // key -> name of the stack it finds, "" when none
+		wantAliases map[string][]string // stack name -> aliases
+	}{
+		{
+			name:        "new stack",
+			registered:  []Stack{{Name: "lamp"}},
+			replacement: Stack{Name: "lemp", Aliases: []string{"nginx"}},
+			want:        map[string]string{"lamp": "lamp", "lemp": "lemp", "nginx": "lemp"},
+		},
+		{
+			name:        "same name drops the old aliases",
+			registered:  []Stack{{Name: "mariadb", Aliases: []string{"mysql", "maria"}}},
+			replacement: Stack{Name: "mariadb", Aliases: []string{"db"}, Source: SourceUser},
+			want:        map[string]string{"mariadb": "mariadb", "db": "mariadb", "mysql": "", "maria": ""},
+			wantAliases: map[string][]string{"mariadb": {"db"}},
+		},
+		{
+			name:        "alias never hides another stack",
+			registered:  []Stack{{Name: "mariadb"}, {Name: "lamp"}},
+			replacement: Stack{Name: "mydb", Aliases: []string{"mariadb", "db"}},
+			want:        map[string]string{"mariadb": "mariadb", "mydb": "mydb", "db": "mydb"},
+			wantAliases: map[string][]string{"mydb": {"db"}, "mariadb": nil},
+		},
+		{
+			name:        "alias taken from another stack",
+			registered:  []Stack{{Name: "mariadb", Aliases: []string{"mysql", "db"}}},
+			replacement: Stack{Name: "postgres", Aliases: []string{"db"}},
+			want:        map[string]string{"mariadb": "mariadb", "mysql": "mariadb", "db": "postgres", "postgres": "postgres"},
+			wantAliases: map[string][]string{"mariadb": {"mysql"}, "postgres": {"db"}},
+		},
+		{
+			name:        "name taken from the alias of another stack",
+			registered:  []Stack{{Name: "mariadb", Aliases: []string{"mysql"}}},
+			replacement: Stack{Name: "mysql"},
+			want:        map[string]string{"mariadb": "mariadb", "mysql": "mysql"},
+			wantAliases: map[string][]string{"mariadb": nil},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useRegistry(t, tt.registered...)
+			Replace(tt.replacement)
+
+			for key, want := range tt.want {
+				s, ok := Lookup(key)
+				if got := s.Name; !ok && want != "" || ok && got != want {
+					t.Errorf("Lookup(%q) = %q, %v, want %q", key, got, ok, want)
+				}
+			}
+			for name, want := range tt.wantAliases {
+				s, ok := Lookup(name)
+				if !ok {
+					t.Fatalf("Lookup(%q) found nothing", name)
+				}
+				if !reflect.DeepEqual(s.Aliases, want) {
+					t.Errorf("aliases of %s = %v, want %v", name, s.Aliases, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLookupIgnoresCase(t *testing.T) {
+	useRegistry(t, Stack{Name: "mariadb", Aliases: []string{"mysql"}})
+
+	for _, name := range []string{"mariadb", "MariaDB", "MYSQL"} {
+		if s, ok := Lookup(name); !ok || s.Name != "mariadb" {
+			t.Errorf("Lookup(%q) = %q, %v, want mariadb", name, s.Name, ok)
+		}
+	}
+}
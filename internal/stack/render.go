@@ -0,0 +1,345 @@
+package stack
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"path"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// passwordAlphabet is used by the randPassword template function
+const passwordAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// ProjectNameKey is the template key holding the compose project name
+const ProjectNameKey = "PROJECT_NAME"
+
+// Template keys set to "true" when values live in .env or secret files
+const (
+	EnvFileKey     = "ENV_FILE"
+	SecretFilesKey = "SECRET_FILES"
+)
+
+// templateFuncs are the helper functions available to stack templates
+var templateFuncs = template.FuncMap{
+	"default":      defaultValue,
+	"quote":        strconv.Quote,
+	"upper":        strings.ToUpper,
+	"randPassword": randPassword,
+	"indent":       indent,
+}
+
+// TemplateData builds the data passed to templates: the project name as
+// PROJECT_NAME, whether values live in .env or secret files as ENV_FILE and
+// SECRET_FILES, every environment variable by name and every port as
+// PORT_<SERVICE>. Variables and ports disabled by their condition are empty.
+func (config StackConfig) TemplateData(envValues, portValues map[string]string) map[string]string {
+	data := make(map[string]string, len(envValues)+len(portValues)+3)
+	data[ProjectNameKey] = config.ProjectName
+	data[EnvFileKey] = flag(config.EnvFile)
+	data[SecretFilesKey] = flag(config.SecretFiles)
+	for _, v := range config.EnvVars {
+		data[v.VarName] = ""
+	}
+	for _, p := range config.ConfigurePorts {
+		data[PortKey(p.ServiceName)] = ""
+	}
+	for key, value := range envValues {
+		data[key] = value
+	}
+	for service, port := range portValues {
+		data[PortKey(service)] = port
+	}
+	return data
+}
+
+// PortKey returns the template key of a service port
+func PortKey(service string) string {
+	return "PORT_" + strings.ToUpper(service)
+}
+
+// Render executes every file of the stack as a template with the given data,
+// except files matching a raw pattern, which are kept as they are. Files and
+// directories whose condition is false are left out of the project.
+// Referencing a key missing from data is an error that names the file and line.
+// Files whose output contains a secret are also rendered with the secrets
+// masked into config.Masked. The secrets referenced with secretFile are then
+// written to secret files with SecretFiles and the values referenced with
+// ref to .env and .env.example with EnvFile.
+func (config *StackConfig) Render(data map[string]string) error {
+	refs := newTemplateRefs()
+	masked := config.maskSecrets(data)
+	config.Masked = make(map[string]string)
+	var dirs []string
+	for _, dir := range config.Dirs {
+		if config.Enabled(dir, data) {
+			dirs = append(dirs, dir)
+		}
+	}
+	config.Dirs = dirs
+
+	for _, path := range config.Paths() {
+		if !config.Enabled(path, data) {
+			delete(config.Files, path)
+			continue
+		}
+		if config.IsRaw(path) {
+			continue
+		}
+		tmpl := config.Files[path]
+		refs.read = make(map[string]bool)
+		content, err := renderTemplate(path, tmpl, data, refs)
+		if err != nil {
+			return err
+		}
+		config.Files[path] = content
+
+		if config.readsSecret(refs.read, data) {
+			if config.Masked[path], err = renderTemplate(path, tmpl, masked, newTemplateRefs()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if config.SecretFiles {
+		config.AddSecretFiles(data, refs.secrets)
+	}
+	if config.EnvFile {
+		config.AddEnvFile(data, refs.env)
+	}
+	return nil
+}
+
+// maskSecrets returns a copy of data with every secret value replaced by
+// secretMask
+func (config StackConfig) maskSecrets(data map[string]string) map[string]string {
+	masked := make(map[string]string, len(data))
+	for key, value := range data {
+		masked[key] = value
+	}
+	for _, v := range config.EnvVars {
+		if v.Secret() && data[v.VarName] != "" {
+			masked[v.VarName] = secretMask
+		}
+	}
+	return masked
+}
+
+// readsSecret reports whether a file whose output contains the variables
+// in read holds the value of a secret
+func (config StackConfig) readsSecret(read map[string]bool, data map[string]string) bool {
+	for _, v := range config.EnvVars {
+		if read[v.VarName] && v.Secret() && data[v.VarName] != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// HoldsSecret reports whether a generated file contains a secret value:
+// .env, the files under secrets/ and the files listed in Masked
+func (config StackConfig) HoldsSecret(relPath string) bool {
+	if relPath == EnvFile || strings.HasPrefix(relPath, SecretsDir+"/") {
+		return true
+	}
+	_, ok := config.Masked[relPath]
+	return ok
+}
+
+// Enabled reports whether a file or directory is generated: it is unless
+// a condition whose pattern matches it is false
+func (config StackConfig) Enabled(relPath string, data map[string]string) bool {
+	for _, c := range config.Conditions {
+		if ok, _ := path.Match(c.Pattern, relPath); ok && !evalWhen(c.When, data) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsRaw reports whether a file is copied without rendering. Patterns use
+// path.Match and apply to the path relative to the project, so
+// grafana/dashboards/*.json matches every dashboard of that directory.
+func (config StackConfig) IsRaw(relPath string) bool {
+	for _, pattern := range config.Raw {
+		if ok, _ := path.Match(pattern, relPath); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// RenderTemplate executes a single template
+func RenderTemplate(name, content string, data map[string]string) (string, error) {
+	return renderTemplate(name, content, data, newTemplateRefs())
+}
+
+// templateRefs records the variables referenced by the rendered templates
+type templateRefs struct {
+	env     map[string]bool // referenced with ref
+	secrets map[string]bool // referenced with secretFile
+	read    map[string]bool // whose value is written into the rendered file
+}
+
+func newTemplateRefs() templateRefs {
+	return templateRefs{env: make(map[string]bool), secrets: make(map[string]bool), read: make(map[string]bool)}
+}
+
+// renderTemplate executes a single template and records in refs the
+// variables it references
+func renderTemplate(name, content string, data map[string]string, refs templateRefs) (string, error) {
+	tmpl, err := template.New(name).
+		Funcs(templateFuncs).
+		Funcs(template.FuncMap{
+			"ref":        refFunc(data, refs.env, refs.read),
+			"secretFile": secretFileFunc(data, refs.secrets),
+			"when":       whenFunc(data),
+		}).
+		Option("missingkey=error").
+		Parse(content)
+	if err != nil {
+		return "", fmt.Errorf("error parsing %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("error rendering %w", err)
+	}
+	printedFields(tmpl.Tree.Root, refs.read)
+	return buf.String(), nil
+}
+
+// printedFields records the variables whose value an action of the template
+// may write, such as {{.VAR}} or {{quote .VAR}}. The conditions of if only
+// test a value and are not counted, those of with and range are since dot
+// then holds the value.
+func printedFields(node parse.Node, read map[string]bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			printedFields(child, read)
+		}
+	case *parse.ActionNode:
+		pipeFields(n.Pipe, read)
+	case *parse.IfNode:
+		printedFields(n.List, read)
+		printedFields(n.ElseList, read)
+	case *parse.WithNode:
+		pipeFields(n.Pipe, read)
+		printedFields(n.List, read)
+		printedFields(n.ElseList, read)
+	case *parse.RangeNode:
+		pipeFields(n.Pipe, read)
+		printedFields(n.List, read)
+		printedFields(n.ElseList, read)
+	}
+}
+
+// pipeFields records the fields read by a pipeline
+func pipeFields(pipe *parse.PipeNode, read map[string]bool) {
+	if pipe == nil {
+		return
+	}
+	for _, cmd := range pipe.Cmds {
+		for _, arg := range cmd.Args {
+			switch a := arg.(type) {
+			case *parse.FieldNode:
+				read[a.Ident[0]] = true
+			case *parse.PipeNode:
+				pipeFields(a, read)
+			}
+		}
+	}
+}
+
+// flag returns a template value that is true in {{if}} when set
+func flag(set bool) string {
+	if set {
+		return "true"
+	}
+	return ""
+}
+
+// refFunc returns the ref template function: it references a variable as
+// ${KEY} when values live in .env and inlines the value otherwise. Every
+// referenced key is recorded in refs, and inlined keys in read.
+func refFunc(data map[string]string, refs, read map[string]bool) func(string) (string, error) {
+	return func(key string) (string, error) {
+		value, ok := data[key]
+		if !ok {
+			return "", fmt.Errorf("variable %s is not defined", key)
+		}
+		refs[key] = true
+		if data[EnvFileKey] != "" {
+			return "${" + key + "}", nil
+		}
+		read[key] = true
+		return value, nil
+	}
+}
+
+// secretFileFunc returns the secretFile template function: it returns the
+// path of the file holding a secret, relative to the compose file, and
+// records the secret in refs so the file is written
+func secretFileFunc(data map[string]string, refs map[string]bool) func(string) (string, error) {
+	return func(key string) (string, error) {
+		if _, ok := data[key]; !ok {
+			return "", fmt.Errorf("variable %s is not defined", key)
+		}
+		refs[key] = true
+		return "./" + SecretFile(key), nil
+	}
+}
+
+// whenFunc returns the when template function, which evaluates a condition
+// written like the `when` of a manifest
+func whenFunc(data map[string]string) func(string) (bool, error) {
+	return func(expr string) (bool, error) {
+		c, err := parseCondition(expr)
+		if err != nil {
+			return false, err
+		}
+		return c.eval(data), nil
+	}
+}
+
+// defaultValue returns value, or def when value is empty
+func defaultValue(def, value string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
+
+// randPassword returns a random alphanumeric string of the given length
+func randPassword(length int) (string, error) {
+	return randomString(length, passwordAlphabet)
+}
+
+// randomString returns a string of the given length drawn uniformly from
+// alphabet with crypto/rand
+func randomString(length int, alphabet string) (string, error) {
+	max := big.NewInt(int64(len(alphabet)))
+	result := make([]byte, length)
+	for i := range result {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		result[i] = alphabet[n.Int64()]
+	}
+	return string(result), nil
+}
+
+// indent prefixes every line of text with the given number of spaces
+func indent(spaces int, text string) string {
+	pad := strings.Repeat(" ", spaces)
+	return pad + strings.ReplaceAll(text, "\n", "\n"+pad)
+}
@@ -0,0 +1,39 @@
+package stack
+
+import (
+	"path"
+	"strings"
+)
+
+// SecretsDir holds one file per secret when secrets are written as files
+const SecretsDir = "secrets"
+
+// Modes accepted by --secrets
+const (
+	SecretsEnv   = "env"
+	SecretsFiles = "files"
+)
+
+// SecretFile returns the path of the file holding a secret variable,
+// secrets/<lowercase name>.txt. Templates get it with secretFile and mount it
+// as a compose secret named after the lowercase variable name.
+func SecretFile(name string) string {
+	return path.Join(SecretsDir, strings.ToLower(name)+".txt")
+}
+
+// AddSecretFiles writes the secret variables referenced with secretFile to
+// their own file under secrets/ and keeps the directory out of git. The
+// files hold the bare value without a trailing newline, as read by the
+// *_FILE variables of the images. Nothing is added when no file is used.
+func (config *StackConfig) AddSecretFiles(values map[string]string, refs map[string]bool) {
+	written := false
+	for _, v := range config.EnvVars {
+		if refs[v.VarName] && v.Secret() && v.Active(values) {
+			config.Files[SecretFile(v.VarName)] = values[v.VarName]
+			written = true
+		}
+	}
+	if written {
+		config.ignore(SecretsDir + "/")
+	}
+}
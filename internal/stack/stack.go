@@ -1,43 +1,260 @@
 package stack
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io/fs"
+
+	"github.com/bait-py/autostack/internal/compose"
 )
 
+// ErrCancelled is returned when the user does not confirm the configuration
+var ErrCancelled = errors.New("cancelled")
+
 // Create creates a stack based on the specified name
-func Create(name string) error {
-	switch name {
-	case "lamp":
-		return createLamp()
-	case "observability", "obs":
-		return createObservability()
-	case "mariadb":
-		return createMariaDB()
-	default:
+func Create(ctx context.Context, name string, opts Options) error {
+	ui := opts.UI.orStd()
+
+	s, ok := Lookup(name)
+	if !ok {
 		return errors.New("stack not recognized: " + name)
 	}
+	ui.Verbosef("Using %s stack %s %s\n", s.Source, s.Name, versionOrHash(s.Version, s.Hash))
+	if err := s.Validate(opts); err != nil {
+		return err
+	}
+
+	config := s.NewConfig(opts)
+	if err := CheckProjectName(config.ProjectName, config.ProjectDir); err != nil {
+		return err
+	}
+	// Fail before asking anything if existing files would be clobbered.
+	// A dry run shows them as a diff instead.
+	if !opts.DryRun {
+		if err := config.CheckExisting(); err != nil {
+			return err
+		}
+	}
+
+	if err := s.Resolve(ui, &config, opts); err != nil {
+		if errors.Is(err, ErrCancelled) {
+			return nil
+		}
+		return err
+	}
+
+	if opts.DryRun {
+		return PrintDryRun(ui, config)
+	}
+
+	// Prompt for auto-start
+	switch {
+	case opts.NoStart:
+		config.AutoStart = false
+	case opts.Yes:
+		config.AutoStart = true
+	default:
+		config.AutoStart = PromptAutoStart(ui)
+	}
+
+	config.Runner = opts.Runner
+	if config.AutoStart && config.Runner == nil {
+		runner, err := compose.NewWithStreams(ctx, opts.Compose, ui.In, ui.Out, ui.Err)
+		if err != nil {
+			return err
+		}
+		config.Runner = runner
+	}
+
+	// The files are written when compose fails to start, which GenerateStack
+	// reports as a warning along with the command to start it manually
+	err := GenerateStack(ctx, ui, config)
+	var startErr *StartError
+	if errors.As(err, &startErr) {
+		return nil
+	}
+	return err
+}
+
+// NewConfig returns the configuration of s with the project name, output
+// directory and storage modes chosen in opts
+func (s Stack) NewConfig(opts Options) StackConfig {
+	config := s.Config()
+	config.Existing = opts.Existing
+	config.EnvFile = !opts.InlineEnv
+	config.SecretFiles = opts.SecretFiles
+	if opts.Name != "" {
+		config.ProjectName = opts.Name
+		config.ProjectDir = opts.Name
+	}
+	if opts.Dir != "" {
+		config.ProjectDir = opts.Dir
+		// Projects in different directories need their own compose project
+		// name, or they share containers and networks
+		if name := ProjectNameFromDir(opts.Dir); opts.Name == "" && name != "" {
+			config.ProjectName = name
+		}
+	}
+	return config
 }
 
-// ListStacks shows all available stacks
-func ListStacks() {
-	stacks := []struct {
-		Name        string
-		Alias       string
-		Description string
-	}{
-		{"lamp", "", "LAMP stack with Apache, MySQL, PHP and phpMyAdmin"},
-		{"observability", "obs", "Prometheus + Grafana + Node Exporter for monitoring"},
-		{"mariadb", "", "MariaDB + phpMyAdmin for databases"},
-	}
-
-	fmt.Println("\nAvailable stacks:")
-	for _, s := range stacks {
-		if s.Alias != "" {
-			fmt.Printf("  %s (%s) - %s\n", s.Name, s.Alias, s.Description)
+// Resolve collects the values of s, prompting for those missing from opts
+// unless opts.Yes is set, checks the ports and renders the templates into
+// config along with its lockfile. It returns ErrCancelled when the user
+// does not confirm the configuration.
+func (s Stack) Resolve(ui *UI, config *StackConfig, opts Options) error {
+	// Prompt for environment variables and ports not supplied up front.
+	// Variables and ports disabled by a condition are left out.
+	envValues := make(map[string]string)
+	for _, v := range s.EnvVars {
+		if value, ok := opts.Env[v.VarName]; ok {
+			envValues[v.VarName] = value
+		}
+	}
+	if !opts.Yes {
+		if err := PromptEnvVars(ui, s.EnvVars, envValues); err != nil {
+			return err
+		}
+	}
+
+	// Recreating a project keeps the secrets its data was initialised with
+	// instead of generating new ones
+	existing := s.existingSecrets(ui, config.ProjectDir)
+	for _, key := range sortedKeys(existing) {
+		if _, ok := envValues[key]; !ok {
+			ui.Verbosef("Keeping %s of the existing project\n", key)
+			envValues[key] = existing[key]
+		}
+	}
+	generated, err := applyEnvDefaults(s.EnvVars, envValues)
+	if err != nil {
+		return err
+	}
+
+	ports := s.ActivePorts(envValues)
+	var pendingPorts []StackPort
+	portValues := make(map[string]string)
+	for _, p := range ports {
+		if port, ok := opts.Ports[p.ServiceName]; ok {
+			portValues[p.ServiceName] = port
+		} else if opts.Yes {
+			portValues[p.ServiceName] = p.Default
 		} else {
-			fmt.Printf("  %s - %s\n", s.Name, s.Description)
+			pendingPorts = append(pendingPorts, p)
+		}
+	}
+	// Conflicts of prompted ports are asked again, unless they are left
+	// to --auto-ports or not checked at all
+	var checker *PortChecker
+	if len(pendingPorts) > 0 && !opts.SkipPortCheck && !opts.AutoPorts {
+		if checker, err = NewPortChecker(config.ProjectDir); err != nil {
+			return err
+		}
+		for service, port := range portValues {
+			if n, err := ValidatePort(port, opts.AllowPrivileged); err == nil {
+				checker.Use(n, service)
+			}
+		}
+	}
+	promptedPorts, err := PromptPorts(ui, pendingPorts, opts.AllowPrivileged, checker)
+	if err != nil {
+		return err
+	}
+	for service, port := range promptedPorts {
+		portValues[service] = port
+	}
+
+	// Check ports against the host and other generated projects
+	if opts.SkipPortCheck {
+		for _, p := range ports {
+			if _, err := ValidatePort(portValues[p.ServiceName], opts.AllowPrivileged); err != nil {
+				return fmt.Errorf("%s: %w", p.ServiceName, err)
+			}
+		}
+	} else {
+		changes, err := CheckPorts(ports, portValues, PortOptions{
+			AutoPorts:       opts.AutoPorts,
+			AllowPrivileged: opts.AllowPrivileged,
+			ProjectDir:      config.ProjectDir,
+		})
+		if err != nil {
+			return err
+		}
+		PrintPortChanges(ui, changes)
+	}
+
+	// Confirm configuration
+	if !opts.Yes && !ConfirmConfiguration(ui, s.EnvVars, envValues, portValues) {
+		return ErrCancelled
+	}
+
+	config.ApplyPorts(portValues)
+	config.EnvValues = envValues
+	config.PortValues = portValues
+	config.GeneratedSecrets = generated
+
+	// Render templates with environment variables and ports
+	if err := config.Render(config.TemplateData(envValues, portValues)); err != nil {
+		return err
+	}
+
+	config.Lock = NewLock(s, config.ProjectName, envValues, portValues)
+	config.Lock.EnvFile = config.EnvFile
+	config.Lock.SecretFiles = config.SecretFiles
+	return nil
+}
+
+// existingSecrets returns the secrets of a project already generated from s
+// in dir, or nil when there is none
+func (s Stack) existingSecrets(ui *UI, dir string) map[string]string {
+	lock, err := ReadLock(dir)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			ui.Warnf("Could not read the lockfile of the existing project: %v\n", err)
+		}
+		return nil
+	}
+	if lock.Stack.Name != s.Name {
+		return nil
+	}
+
+	env, err := lockedEnv(dir, lock)
+	if err != nil {
+		ui.Warnf("Could not read the secrets of the existing project, new ones are generated: %v\n", err)
+		return nil
+	}
+	secrets := make(map[string]string, len(lock.Secrets))
+	for _, key := range lock.Secrets {
+		secrets[key] = env[key]
+	}
+	return secrets
+}
+
+// applyEnvDefaults fills the variables without a value with their default,
+// drops the variables disabled by their condition and validates every value.
+// It returns the secrets generated at random.
+func applyEnvDefaults(vars []StackEnvVars, values map[string]string) (map[string]string, error) {
+	generated := make(map[string]string)
+	for _, v := range vars {
+		if !v.Active(values) {
+			delete(values, v.VarName)
+			continue
+		}
+		if _, ok := values[v.VarName]; !ok {
+			value, err := v.DefaultValue()
+			if err != nil {
+				return nil, fmt.Errorf("error generating %s: %w", v.VarName, err)
+			}
+			values[v.VarName] = value
+			if v.Generated() {
+				generated[v.VarName] = value
+			}
+		}
+
+		if err := v.Validate(values[v.VarName]); err != nil {
+			return nil, err
 		}
 	}
-	fmt.Println()
+	return generated, nil
 }
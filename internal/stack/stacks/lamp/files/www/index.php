@@ -0,0 +1,17 @@
+<?php
+phpinfo();
+
+// MySQL connection test
+$host = 'db';
+$db   = getenv('MYSQL_DATABASE');
+$user = getenv('MYSQL_USER');
+$passFile = getenv('MYSQL_PASSWORD_FILE');
+$pass = $passFile ? trim(file_get_contents($passFile)) : getenv('MYSQL_PASSWORD');
+
+try {
+    $pdo = new PDO("mysql:host=$host;dbname=$db", $user, $pass);
+    echo "<h2>Successful connection to MySQL!</h2>";
+} catch (PDOException $e) {
+    echo "<h2>Connection error: " . $e->getMessage() . "</h2>";
+}
+?>
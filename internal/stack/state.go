@@ -0,0 +1,204 @@
+package stack
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// StateEnv overrides the directory holding the per-user state
+const StateEnv = "AUTOSTACK_STATE_DIR"
+
+// ProjectRecord is an entry of the per-user registry of generated projects
+type ProjectRecord struct {
+	Name    string            `json:"name"`
+	Path    string            `json:"path"`
+	Stack   string            `json:"stack"`
+	Ports   map[string]string `json:"ports,omitempty"` // label -> host port
+	Created time.Time         `json:"created"`
+}
+
+// Exists reports whether the project directory is still on disk
+func (r ProjectRecord) Exists() bool {
+	info, err := os.Stat(r.Path)
+	return err == nil && info.IsDir()
+}
+
+// StateDir returns the directory holding the per-user state:
+// $AUTOSTACK_STATE_DIR, $XDG_STATE_HOME/autostack or ~/.local/state/autostack
+func StateDir() (string, error) {
+	if dir := os.Getenv(StateEnv); dir != "" {
+		return dir, nil
+	}
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return filepath.Join(dir, "autostack"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "state", "autostack"), nil
+}
+
+// projectsFile returns the path of the project registry
+func projectsFile() (string, error) {
+	dir, err := StateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "projects.json"), nil
+}
+
+// LoadProjects returns every recorded project sorted by name
+func LoadProjects() ([]ProjectRecord, error) {
+	path, err := projectsFile()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var records []ProjectRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].Name != records[j].Name {
+			return records[i].Name < records[j].Name
+		}
+		return records[i].Path < records[j].Path
+	})
+	return records, nil
+}
+
+// SaveProjects replaces the project registry
+func SaveProjects(records []ProjectRecord) error {
+	path, err := projectsFile()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so a crash never leaves it truncated
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// RecordProject adds a project to the registry, replacing any previous
+// record for the same directory
+func RecordProject(record ProjectRecord) error {
+	abs, err := filepath.Abs(record.Path)
+	if err != nil {
+		return err
+	}
+	record.Path = abs
+
+	records, err := LoadProjects()
+	if err != nil {
+		return err
+	}
+
+	kept := records[:0]
+	for _, r := range records {
+		if r.Path != record.Path {
+			kept = append(kept, r)
+		}
+	}
+	return SaveProjects(append(kept, record))
+}
+
+// PruneProjects removes the records of projects whose directory no longer
+// exists and returns them
+func PruneProjects() ([]ProjectRecord, error) {
+	records, err := LoadProjects()
+	if err != nil {
+		return nil, err
+	}
+
+	var kept, removed []ProjectRecord
+	for _, r := range records {
+		if r.Exists() {
+			kept = append(kept, r)
+		} else {
+			removed = append(removed, r)
+		}
+	}
+
+	if len(removed) == 0 {
+		return nil, nil
+	}
+	return removed, SaveProjects(kept)
+}
+
+// errAmbiguousProject is returned when a name matches several projects
+var errAmbiguousProject = errors.New("use the directory instead")
+
+func ambiguousProject(count int, name string) error {
+	return fmt.Errorf("%d projects are named %s, %w", count, name, errAmbiguousProject)
+}
+
+// CheckProjectName refuses a compose project name already used by another
+// recorded project, since both would share the same containers
+func CheckProjectName(name, dir string) error {
+	records, err := LoadProjects()
+	if err != nil {
+		return err
+	}
+
+	own, _ := filepath.Abs(dir)
+	for _, r := range records {
+		if r.Name == name && r.Path != own && r.Exists() {
+			return fmt.Errorf("project name %s is already used by %s, choose another name", name, r.Path)
+		}
+	}
+	return nil
+}
+
+// FindRecord finds a recorded project by name or directory
+func FindRecord(ref string) (ProjectRecord, error) {
+	records, err := LoadProjects()
+	if err != nil {
+		return ProjectRecord{}, err
+	}
+
+	abs, _ := filepath.Abs(ref)
+	var matches []ProjectRecord
+	for _, r := range records {
+		if r.Path == abs {
+			return r, nil
+		}
+		if r.Name == ref {
+			matches = append(matches, r)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return ProjectRecord{}, fmt.Errorf("project not found: %s", ref)
+	case 1:
+		return matches[0], nil
+	default:
+		return ProjectRecord{}, ambiguousProject(len(matches), ref)
+	}
+}
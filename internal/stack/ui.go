@@ -0,0 +1,83 @@
+package stack
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Level controls how much a UI prints
+type Level int
+
+const (
+	// Quiet prints only prompts, requested content, warnings and errors
+	Quiet Level = iota - 1
+	// Normal prints progress and summaries
+	Normal
+	// Verbose also prints every step
+	Verbose
+)
+
+// UI carries the streams used to talk to the user. Every function of the
+// package that prompts or prints goes through a UI, so the whole create
+// flow can be driven from tests or another program.
+type UI struct {
+	In    io.Reader
+	Out   io.Writer
+	Err   io.Writer
+	Level Level
+
+	prompter *prompter // created on first use so buffered input is shared
+}
+
+// NewUI creates a UI at the Normal level
+func NewUI(in io.Reader, out, errOut io.Writer) *UI {
+	return &UI{In: in, Out: out, Err: errOut, Level: Normal}
+}
+
+// StdUI returns a UI on the standard streams of the process
+func StdUI() *UI {
+	return NewUI(os.Stdin, os.Stdout, os.Stderr)
+}
+
+// orStd returns ui, or a UI on the standard streams when ui is nil
+func (ui *UI) orStd() *UI {
+	if ui == nil {
+		return StdUI()
+	}
+	return ui
+}
+
+// prompt returns the prompter reading from In
+func (ui *UI) prompt() *prompter {
+	if ui.prompter == nil {
+		ui.prompter = newPrompter(ui.In, ui.Out)
+	}
+	return ui.prompter
+}
+
+// Printf prints progress and summaries, unless the UI is quiet
+func (ui *UI) Printf(format string, args ...any) {
+	if ui.Level >= Normal {
+		fmt.Fprintf(ui.Out, format, args...)
+	}
+}
+
+// Println prints progress and summaries, unless the UI is quiet
+func (ui *UI) Println(args ...any) {
+	if ui.Level >= Normal {
+		fmt.Fprintln(ui.Out, args...)
+	}
+}
+
+// Verbosef prints details of every step when the UI is verbose
+func (ui *UI) Verbosef(format string, args ...any) {
+	if ui.Level >= Verbose {
+		fmt.Fprintf(ui.Out, format, args...)
+	}
+}
+
+// Warnf prints a warning on the error stream at every level
+func (ui *UI) Warnf(format string, args ...any) {
+	fmt.Fprintf(ui.Err, "WARNING: "+format, args...)
+}
@@ -0,0 +1,299 @@
+package stack
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// UpgradeOptions control how a project is upgraded
+type UpgradeOptions struct {
+	DryRun bool // report what would change without writing anything
+	UI     *UI  // streams used for output, the standard streams when nil
+}
+
+// UpgradeResult summarizes what an upgrade changed
+type UpgradeResult struct {
+	Updated    []string // merged cleanly with the new template
+	Added      []string // new in the template
+	Removed    []string // removed from the template and unmodified locally
+	Kept       []string // removed from the template but modified locally
+	Conflicted []string // merged with conflict markers
+	NewVars    []string // variables introduced by the template, set to their defaults
+}
+
+// Upgrade re-renders the project in dir with the current version of its
+// stack and merges the changes into the files on disk. The original
+// template is rendered again from the snapshot taken at generation time, so
+// local edits are preserved and only template changes are applied.
+func Upgrade(dir string, opts UpgradeOptions) error {
+	ui := opts.UI.orStd()
+
+	lock, err := ReadLock(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%s was not generated by autostack: %s not found", dir, LockFile)
+	}
+	if err != nil {
+		return err
+	}
+
+	current, ok := Lookup(lock.Stack.Name)
+	if !ok {
+		return errors.New("stack not recognized: " + lock.Stack.Name)
+	}
+	if current.Name != lock.Stack.Name {
+		return fmt.Errorf("%s was generated from stack %s, but that name now selects stack %s", dir, lock.Stack.Name, current.Name)
+	}
+	if current.Hash == lock.Stack.Hash {
+		ui.Printf("%s is already up to date with stack %s\n", dir, current.Name)
+		return nil
+	}
+
+	original, err := ReadSnapshot(dir)
+	if err != nil {
+		return fmt.Errorf("error reading template snapshot from %s: %w", SnapshotDir, err)
+	}
+
+	envValues, err := lockedEnv(dir, lock)
+	if err != nil {
+		return err
+	}
+
+	oldConfig := original.Config()
+	oldConfig.ProjectName = lock.Project
+	oldConfig.EnvFile = lock.EnvFile
+	oldConfig.SecretFiles = lock.SecretFiles
+	if err := oldConfig.Render(oldConfig.TemplateData(envValues, lock.Ports)); err != nil {
+		return fmt.Errorf("error rendering original template: %w", err)
+	}
+
+	// Variables and ports added by the new template take their defaults
+	var result UpgradeResult
+	newEnv := make(map[string]string)
+	for _, v := range current.EnvVars {
+		if !v.Active(newEnv) {
+			continue
+		}
+		value, ok := envValues[v.VarName]
+		if !ok {
+			if value, err = v.DefaultValue(); err != nil {
+				return err
+			}
+			result.NewVars = append(result.NewVars, v.VarName)
+		}
+		newEnv[v.VarName] = value
+	}
+	newPorts := make(map[string]string)
+	for _, p := range current.ActivePorts(newEnv) {
+		port, ok := lock.Ports[p.ServiceName]
+		if !ok {
+			port = p.Default
+			result.NewVars = append(result.NewVars, PortKey(p.ServiceName))
+		}
+		newPorts[p.ServiceName] = port
+	}
+
+	newConfig := current.Config()
+	newConfig.ProjectName = lock.Project
+	newConfig.ProjectDir = dir
+	newConfig.EnvFile = lock.EnvFile
+	newConfig.SecretFiles = lock.SecretFiles
+	newConfig.ApplyPorts(newPorts)
+	if err := newConfig.Render(newConfig.TemplateData(newEnv, newPorts)); err != nil {
+		return fmt.Errorf("error rendering new template: %w", err)
+	}
+
+	writes, removals, err := planUpgrade(dir, oldConfig.Files, newConfig.Files, &result)
+	if err != nil {
+		return err
+	}
+
+	printUpgradeResult(ui, dir, lock, current, result, opts.DryRun)
+	if opts.DryRun {
+		return nil
+	}
+
+	for _, d := range newConfig.Dirs {
+		if err := os.MkdirAll(filepath.Join(dir, d), 0755); err != nil {
+			return fmt.Errorf("error creating directory %s: %w", d, err)
+		}
+	}
+	for relPath, content := range writes {
+		fullPath := filepath.Join(dir, relPath)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			return err
+		}
+		if err := newConfig.writeFile(fullPath, relPath, content); err != nil {
+			return fmt.Errorf("error writing %s: %w", relPath, err)
+		}
+	}
+	for _, relPath := range removals {
+		if err := os.Remove(filepath.Join(dir, relPath)); err != nil {
+			return fmt.Errorf("error removing %s: %w", relPath, err)
+		}
+	}
+
+	newLock := NewLock(current, lock.Project, newEnv, newPorts)
+	newLock.Created = lock.Created
+	newLock.EnvFile = lock.EnvFile
+	newLock.SecretFiles = lock.SecretFiles
+	newLock.AddFiles(newConfig.Files, newConfig.HoldsSecret)
+	if err := WriteLock(dir, newLock); err != nil {
+		return err
+	}
+
+	newConfig.Lock = newLock
+	if err := RecordProject(newConfig.Record()); err != nil {
+		ui.Warnf("Could not record the project in the local registry: %v\n", err)
+	}
+	return nil
+}
+
+// lockedEnv returns the values recorded in the lock, with secrets read
+// back from the secrets file
+func lockedEnv(dir string, lock *Lock) (map[string]string, error) {
+	env := make(map[string]string)
+	for key, value := range lock.Env {
+		env[key] = value
+	}
+	if len(lock.Secrets) == 0 {
+		return env, nil
+	}
+
+	secrets, err := ReadSecrets(dir)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", SecretsFile, err)
+	}
+	for _, key := range lock.Secrets {
+		value, ok := secrets[key]
+		if !ok {
+			return nil, fmt.Errorf("secret %s missing from %s", key, SecretsFile)
+		}
+		env[key] = value
+	}
+	return env, nil
+}
+
+// planUpgrade decides the new content of every file. It returns the files
+// to write and the files to remove.
+func planUpgrade(dir string, oldFiles, newFiles map[string]string, result *UpgradeResult) (map[string]string, []string, error) {
+	writes := make(map[string]string)
+	var removals []string
+
+	paths := make(map[string]bool)
+	for p := range oldFiles {
+		paths[p] = true
+	}
+	for p := range newFiles {
+		paths[p] = true
+	}
+	sorted := make([]string, 0, len(paths))
+	for p := range paths {
+		sorted = append(sorted, p)
+	}
+	sort.Strings(sorted)
+
+	for _, relPath := range sorted {
+		base, inOld := oldFiles[relPath]
+		theirs, inNew := newFiles[relPath]
+
+		data, err := os.ReadFile(filepath.Join(dir, relPath))
+		exists := err == nil
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, nil, err
+		}
+		ours := string(data)
+
+		switch {
+		case !inNew:
+			// Removed from the template
+			if !exists {
+				continue
+			}
+			if ours == base {
+				removals = append(removals, relPath)
+				result.Removed = append(result.Removed, relPath)
+			} else {
+				result.Kept = append(result.Kept, relPath)
+			}
+
+		case !exists:
+			// New in the template, or deleted locally and still in the template
+			if inOld {
+				continue
+			}
+			writes[relPath] = theirs
+			result.Added = append(result.Added, relPath)
+
+		default:
+			if !inOld {
+				base = ""
+			}
+			merged, conflict := Merge3(base, ours, theirs)
+			if merged == ours {
+				continue
+			}
+			writes[relPath] = merged
+			if conflict {
+				result.Conflicted = append(result.Conflicted, relPath)
+			} else if inOld {
+				result.Updated = append(result.Updated, relPath)
+			} else {
+				result.Added = append(result.Added, relPath)
+			}
+		}
+	}
+
+	return writes, removals, nil
+}
+
+// printUpgradeResult shows a summary of the upgrade
+func printUpgradeResult(ui *UI, dir string, lock *Lock, s Stack, result UpgradeResult, dryRun bool) {
+	ui.Printf("\n=== Upgrade %s ===\n", dir)
+	ui.Printf("Stack: %s %s -> %s\n", s.Name, versionOrHash(lock.Stack.Version, lock.Stack.Hash), versionOrHash(s.Version, s.Hash))
+	if dryRun {
+		ui.Println("Dry run: no files were changed")
+	}
+
+	sections := []struct {
+		title string
+		files []string
+	}{
+		{"Updated", result.Updated},
+		{"Added", result.Added},
+		{"Removed", result.Removed},
+		{"Removed from template, kept because modified", result.Kept},
+		{"Conflicts (resolve the markers by hand)", result.Conflicted},
+		{"New values set to their defaults", result.NewVars},
+	}
+
+	changed := false
+	for _, section := range sections {
+		if len(section.files) == 0 {
+			continue
+		}
+		changed = true
+		ui.Printf("\n%s:\n", section.title)
+		for _, file := range section.files {
+			ui.Printf("  - %s\n", file)
+		}
+	}
+	if !changed {
+		ui.Println("\nNo file changes.")
+	}
+	ui.Println()
+}
+
+// versionOrHash describes a template version for humans
+func versionOrHash(version, hash string) string {
+	if version != "" {
+		return version
+	}
+	if len(hash) > 19 {
+		return hash[:19]
+	}
+	return hash
+}
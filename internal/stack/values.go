@@ -0,0 +1,162 @@
+package stack
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bait-py/autostack/internal/compose"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Options control how a stack is created
+type Options struct {
+	Env      map[string]string // environment variable values supplied up front
+	Ports    map[string]string // service -> host port supplied up front
+	Yes      bool              // use defaults for anything not supplied and skip every prompt
+	NoStart  bool              // never start the stack after creation
+	Name     string            // project name, defaults to the name of Dir or the stack name
+	Dir      string            // output directory, defaults to the project name or the stack default
+	Existing ExistingPolicy    // what to do with files that already exist
+	DryRun   bool              // show what would be generated without writing anything
+	Runner   compose.Runner    // compose implementation used to start the stack
+	Compose  string            // name of the implementation used when Runner is nil, $AUTOSTACK_COMPOSE or auto when empty
+	UI       *UI               // streams used for prompts and output, the standard streams when nil
+
+	AutoPorts       bool // pick free ports instead of failing on conflicts
+	AllowPrivileged bool // accept host ports below 1024
+	InlineEnv       bool // write values into the files instead of .env
+	SecretFiles     bool // write secrets to secrets/ and mount them as compose secrets
+	SkipPortCheck   bool // only validate port numbers, do not check the host and other projects
+}
+
+// projectNamePattern matches the names accepted by Docker Compose
+var projectNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// invalidNameChars matches runs of characters compose does not accept in a
+// project name
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+// ProjectNameFromDir derives a compose project name from an output
+// directory: its base name in lowercase with unsupported characters replaced
+// by '-'. It returns "" when nothing usable is left.
+func ProjectNameFromDir(dir string) string {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	name := invalidNameChars.ReplaceAllString(strings.ToLower(filepath.Base(dir)), "-")
+	name = strings.Trim(name, "-_")
+	if !projectNamePattern.MatchString(name) {
+		return ""
+	}
+	return name
+}
+
+// ValidateProjectName checks that name can be used as a compose project name
+func ValidateProjectName(name string) error {
+	if !projectNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid project name %q: use lowercase letters, digits, '-' and '_', starting with a letter or digit", name)
+	}
+	return nil
+}
+
+// valuesFile is the format of the file passed with --values
+type valuesFile struct {
+	Env   map[string]string `yaml:"env"`
+	Ports map[string]string `yaml:"ports"`
+}
+
+// LoadValuesFile reads environment and port values from a YAML file:
+//
+//	env:
+//	  MYSQL_USER: app
+//	ports:
+//	  web: 9000
+//
+// Keys other than env and ports are an error.
+func LoadValuesFile(path string) (env, ports map[string]string, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var values valuesFile
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&values); err != nil && !errors.Is(err, io.EOF) {
+		return nil, nil, fmt.Errorf("error parsing %s: %w", path, err)
+	}
+	return values.Env, values.Ports, nil
+}
+
+// ParseAssignments parses KEY=VALUE pairs as given to --set and --port
+func ParseAssignments(pairs []string) (map[string]string, error) {
+	result := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid assignment %q, expected KEY=VALUE", pair)
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
+// Validate checks the project name and that every supplied value is
+// declared by the stack
+func (s Stack) Validate(opts Options) error {
+	if opts.Name != "" {
+		if err := ValidateProjectName(opts.Name); err != nil {
+			return err
+		}
+	}
+
+	var unknown []string
+
+	for key, value := range opts.Env {
+		v, ok := s.envVar(key)
+		if !ok {
+			unknown = append(unknown, "variable "+key)
+			continue
+		}
+		if err := v.Validate(value); err != nil {
+			return err
+		}
+	}
+	for service := range opts.Ports {
+		if !s.hasPort(service) {
+			unknown = append(unknown, "port "+service)
+		}
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown values for stack %s: %s", s.Name, strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+func (s Stack) envVar(name string) (StackEnvVars, bool) {
+	for _, v := range s.EnvVars {
+		if v.VarName == name {
+			return v, true
+		}
+	}
+	return StackEnvVars{}, false
+}
+
+func (s Stack) hasPort(service string) bool {
+	for _, p := range s.Ports {
+		if p.ServiceName == service {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,217 @@
+package stack
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// VarKind is the type of a stack variable
+type VarKind string
+
+const (
+	KindString     VarKind = "string"
+	KindSecret     VarKind = "secret"
+	KindInt        VarKind = "int"
+	KindBool       VarKind = "bool"
+	KindEnum       VarKind = "enum"
+	KindHostname   VarKind = "hostname"
+	KindIdentifier VarKind = "identifier"
+)
+
+// Defaults for generated secrets
+const (
+	defaultSecretLength = 24
+	minSecretLength     = 8
+)
+
+// hostnamePattern matches RFC 1123 host names
+var hostnamePattern = regexp.MustCompile(`^([a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)(\.[a-zA-Z0-9]([a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$`)
+
+// identifierPattern matches unquoted MySQL identifiers such as database and
+// user names. Identifiers made only of digits are rejected separately.
+var (
+	identifierPattern = regexp.MustCompile(`^[A-Za-z0-9_$]{1,64}$`)
+	digitsPattern     = regexp.MustCompile(`^[0-9]+$`)
+)
+
+// ParseVarKind checks a kind read from a manifest
+func ParseVarKind(kind string) (VarKind, error) {
+	switch k := VarKind(kind); k {
+	case "":
+		return KindString, nil
+	case KindString, KindSecret, KindInt, KindBool, KindEnum, KindHostname, KindIdentifier:
+		return k, nil
+	default:
+		return "", fmt.Errorf("unknown variable kind %q", kind)
+	}
+}
+
+// Secret reports whether the variable holds a secret. Variables without an
+// explicit kind are treated as secrets when their name says so.
+func (v StackEnvVars) Secret() bool {
+	return v.Kind == KindSecret || IsSecret(v.VarName)
+}
+
+// Generated reports whether the default value is generated at random.
+// Secrets declared with generate: false, such as API tokens issued by
+// another service, must be supplied instead.
+func (v StackEnvVars) Generated() bool {
+	return v.Kind == KindSecret && v.Default == "" && (v.Generate == nil || *v.Generate)
+}
+
+// DefaultValue returns the default, generating a random secret when the
+// variable is a secret without a fixed default
+func (v StackEnvVars) DefaultValue() (string, error) {
+	if !v.Generated() {
+		return v.Default, nil
+	}
+
+	length := v.Length
+	if length == 0 {
+		length = defaultSecretLength
+	}
+	alphabet := v.Alphabet
+	if alphabet == "" {
+		alphabet = passwordAlphabet
+	}
+	return randomString(length, alphabet)
+}
+
+// Hint describes the accepted values for prompts, empty when anything goes
+func (v StackEnvVars) Hint() string {
+	var hints []string
+	switch v.Kind {
+	case KindInt:
+		switch {
+		case v.Min != nil && v.Max != nil:
+			hints = append(hints, fmt.Sprintf("integer from %d to %d", *v.Min, *v.Max))
+		case v.Min != nil:
+			hints = append(hints, fmt.Sprintf("integer from %d", *v.Min))
+		case v.Max != nil:
+			hints = append(hints, fmt.Sprintf("integer up to %d", *v.Max))
+		default:
+			hints = append(hints, "integer")
+		}
+	case KindHostname:
+		hints = append(hints, "host name")
+	case KindIdentifier:
+		hints = append(hints, "letters, digits, _ and $, up to 64 characters")
+	}
+	if v.Pattern != "" {
+		hints = append(hints, "matching "+v.Pattern)
+	}
+	return strings.Join(hints, ", ")
+}
+
+// Validate checks that value matches the kind, pattern and range of the
+// variable
+func (v StackEnvVars) Validate(value string) error {
+	if v.Pattern != "" {
+		re, err := regexp.Compile(v.Pattern)
+		if err != nil {
+			return fmt.Errorf("%s: invalid pattern: %w", v.VarName, err)
+		}
+		if !re.MatchString(value) {
+			return fmt.Errorf("%s must match %s, got %q", v.VarName, v.Pattern, value)
+		}
+	}
+
+	switch v.Kind {
+	case KindInt:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%s must be an integer, got %q", v.VarName, value)
+		}
+		if v.Min != nil && n < *v.Min {
+			return fmt.Errorf("%s must be at least %d, got %d", v.VarName, *v.Min, n)
+		}
+		if v.Max != nil && n > *v.Max {
+			return fmt.Errorf("%s must be at most %d, got %d", v.VarName, *v.Max, n)
+		}
+	case KindBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%s must be true or false, got %q", v.VarName, value)
+		}
+	case KindEnum:
+		for _, choice := range v.Choices {
+			if value == choice {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s must be one of %s, got %q", v.VarName, strings.Join(v.Choices, ", "), value)
+	case KindHostname:
+		if len(value) > 253 || !hostnamePattern.MatchString(value) {
+			return fmt.Errorf("%s must be a valid host name, got %q", v.VarName, value)
+		}
+	case KindIdentifier:
+		if !identifierPattern.MatchString(value) || digitsPattern.MatchString(value) {
+			return fmt.Errorf("%s must be 1 to 64 letters, digits, _ or $ and not only digits, got %q", v.VarName, value)
+		}
+	case KindSecret:
+		if value == "" {
+			return fmt.Errorf("%s must not be empty", v.VarName)
+		}
+	}
+	return nil
+}
+
+// check validates the declaration of the variable itself
+func (v StackEnvVars) check() error {
+	if v.Kind == KindEnum && len(v.Choices) == 0 {
+		return fmt.Errorf("%s: enum variables need choices", v.VarName)
+	}
+	if v.Generate != nil && v.Kind != KindSecret {
+		return fmt.Errorf("%s: generate only applies to secret variables", v.VarName)
+	}
+	if v.Shared && !v.Secret() {
+		return fmt.Errorf("%s: shared only applies to secret variables", v.VarName)
+	}
+	if v.Kind == KindSecret && v.Length != 0 && v.Length < minSecretLength {
+		return fmt.Errorf("%s: secrets must be at least %d characters long", v.VarName, minSecretLength)
+	}
+	if v.Alphabet != "" {
+		if err := checkAlphabet(v.Alphabet); err != nil {
+			return fmt.Errorf("%s: %w", v.VarName, err)
+		}
+	}
+	if (v.Min != nil || v.Max != nil) && v.Kind != KindInt {
+		return fmt.Errorf("%s: min and max only apply to int variables", v.VarName)
+	}
+	if v.Min != nil && v.Max != nil && *v.Min > *v.Max {
+		return fmt.Errorf("%s: min %d is greater than max %d", v.VarName, *v.Min, *v.Max)
+	}
+	if v.Pattern != "" {
+		if _, err := regexp.Compile(v.Pattern); err != nil {
+			return fmt.Errorf("%s: invalid pattern: %w", v.VarName, err)
+		}
+	}
+	if v.Default != "" && !v.Generated() {
+		if err := v.Validate(v.Default); err != nil {
+			return fmt.Errorf("invalid default: %w", err)
+		}
+	}
+	return nil
+}
+
+// checkAlphabet verifies that a secret alphabet has at least two distinct
+// printable ASCII characters. Quotes and $ are refused, since secrets drawn
+// from them would need quoting in .env and YAML.
+func checkAlphabet(alphabet string) error {
+	distinct := make(map[rune]bool)
+	for _, r := range alphabet {
+		if r <= ' ' || r > '~' {
+			return fmt.Errorf("alphabet must only contain printable ASCII characters other than space, found %q", r)
+		}
+		if strings.ContainsRune(`'"$`, r) {
+			return fmt.Errorf("alphabet must not contain %q", r)
+		}
+		distinct[r] = true
+	}
+	if len(distinct) < 2 {
+		return errors.New("alphabet needs at least 2 distinct characters")
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+// Package autostack generates Docker Compose projects from stack templates.
+//
+// It discovers the built-in and user-defined stacks, renders a stack with a
+// set of values into an in-memory file set, and writes the result to disk
+// with its lockfile, exactly as the autostack command does:
+//
+//	if err := autostack.LoadUserStacks(); err != nil {
+//		return err
+//	}
+//	project, err := autostack.Render("lamp", autostack.Options{
+//		Env:   map[string]string{"MYSQL_DATABASE": "shop"},
+//		Ports: map[string]string{"web": "9000"},
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	return project.Write(ctx, autostack.WriteOptions{})
+package autostack
+
+import (
+	"github.com/bait-py/autostack/internal/stack"
+)
+
+// Version returns the autostack version recorded in generated lockfiles
+func Version() string {
+	return stack.Version
+}
+
+// Stack describes an available stack
+type Stack struct {
+	Name        string
+	Aliases     []string
+	Title       string
+	Description string
+	Source      string // built-in, user, AUTOSTACK_PATH or project
+	Version     string
+	Variables   []Variable
+	Ports       []Port
+}
+
+// Variable is a configurable value of a stack
+type Variable struct {
+	Name        string
+	Description string
+	Default     string // empty for secrets generated at random
+	Kind        string // string, secret, int, bool, enum, hostname or identifier
+	Choices     []string
+	Secret      bool
+	When        string // condition on earlier variables, empty when always used
+}
+
+// Port is a configurable host port of a stack
+type Port struct {
+	Service     string
+	Label       string
+	Description string
+	Default     string
+	Internal    string
+	When        string // condition on variables, empty when always used
+}
+
+// LoadUserStacks loads the stacks of the current project, AUTOSTACK_PATH and
+// the user configuration directory. Call it once before looking up stacks to
+// make them available next to the built-in ones. Stacks that cannot be
+// loaded are skipped and reported in the returned error, the others are
+// available anyway.
+func LoadUserStacks() error {
+	return stack.LoadUserStacks()
+}
+
+// Stacks returns every available stack sorted by name
+func Stacks() []Stack {
+	var stacks []Stack
+	for _, s := range stack.Stacks() {
+		stacks = append(stacks, newStack(s))
+	}
+	return stacks
+}
+
+// Lookup finds a stack by name or alias
+func Lookup(name string) (Stack, bool) {
+	s, ok := stack.Lookup(name)
+	if !ok {
+		return Stack{}, false
+	}
+	return newStack(s), true
+}
+
+// newStack converts a registered stack
+func newStack(s stack.Stack) Stack {
+	out := Stack{
+		Name:        s.Name,
+		Aliases:     s.Aliases,
+		Title:       s.Title,
+		Description: s.Description,
+		Source:      s.Source,
+		Version:     s.Version,
+	}
+	for _, v := range s.EnvVars {
+		out.Variables = append(out.Variables, Variable{
+			Name:        v.VarName,
+			Description: v.Description,
+			Default:     v.Default,
+			Kind:        string(v.Kind),
+			Choices:     v.Choices,
+			Secret:      v.Secret(),
+			When:        v.When,
+		})
+	}
+	for _, p := range s.Ports {
+		out.Ports = append(out.Ports, Port{
+			Service:     p.ServiceName,
+			Label:       p.Label,
+			Description: p.Description,
+			Default:     p.Default,
+			Internal:    p.Internal,
+			When:        p.When,
+		})
+	}
+	return out
+}
@@ -0,0 +1,66 @@
+package autostack
+
+import (
+	"context"
+	"io"
+
+	"github.com/bait-py/autostack/internal/stack"
+)
+
+// UI carries the input, output and error streams of the interactive
+// functions, and how much they print
+type UI = stack.UI
+
+// Level controls how much a UI prints
+type Level = stack.Level
+
+const (
+	// Quiet prints only prompts, requested content, warnings and errors
+	Quiet = stack.Quiet
+	// Normal prints progress and summaries
+	Normal = stack.Normal
+	// Verbose also prints every step
+	Verbose = stack.Verbose
+)
+
+// NewUI creates a UI at the Normal level
+func NewUI(in io.Reader, out, errOut io.Writer) *UI {
+	return stack.NewUI(in, out, errOut)
+}
+
+// CreateOptions control the interactive creation of a stack
+type CreateOptions = stack.Options
+
+// Storage of secrets accepted by the --secrets flag: SecretsFiles sets
+// CreateOptions.SecretFiles
+const (
+	SecretsEnv   = stack.SecretsEnv
+	SecretsFiles = stack.SecretsFiles
+)
+
+// Create runs the interactive flow of autostack create: it asks for the
+// values missing from opts on opts.UI unless opts.Yes is set, renders the
+// stack, writes it and optionally starts it
+func Create(ctx context.Context, name string, opts CreateOptions) error {
+	return stack.Create(ctx, name, opts)
+}
+
+// UpgradeOptions control how a project is upgraded
+type UpgradeOptions = stack.UpgradeOptions
+
+// Upgrade merges the changes of the current stack template into the project
+// generated in dir, keeping local edits
+func Upgrade(dir string, opts UpgradeOptions) error {
+	return stack.Upgrade(dir, opts)
+}
+
+// LoadValuesFile reads variable values and ports from a YAML file with env
+// and ports sections
+func LoadValuesFile(path string) (env, ports map[string]string, err error) {
+	return stack.LoadValuesFile(path)
+}
+
+// ParseAssignments parses KEY=VALUE pairs
+func ParseAssignments(pairs []string) (map[string]string, error) {
+	return stack.ParseAssignments(pairs)
+}
@@ -0,0 +1,58 @@
+package autostack
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCreateInteractive(t *testing.T) {
+	t.Setenv("AUTOSTACK_STATE_DIR", t.TempDir())
+	dir := filepath.Join(t.TempDir(), "shop")
+
+	// Default root password, database "shop", default user and password,
+	// no phpMyAdmin, then confirm and start
+	in := strings.NewReader("\nshop\n\n\nn\n\n\n")
+	var out, errOut bytes.Buffer
+	runner := &Fake{}
+
+	err := Create(context.Background(), "mariadb", CreateOptions{
+		Dir:           dir,
+		Ports:         map[string]string{"mariadb": "13306"},
+		SkipPortCheck: true,
+		Runner:        runner,
+		UI:            NewUI(in, &out, &errOut),
+	})
+	if err != nil {
+		t.Fatalf("Create: %v\n%s%s", err, out.String(), errOut.String())
+	}
+
+	env, err := os.ReadFile(filepath.Join(dir, ".env"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(env), "MYSQL_DATABASE=shop\n") {
+		t.Errorf(".env does not hold the answered database:\n%s", env)
+	}
+
+	compose, err := os.ReadFile(filepath.Join(dir, "docker-compose.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(compose), `"13306:3306"`) {
+		t.Errorf("docker-compose.yml does not publish the given port:\n%s", compose)
+	}
+	if strings.Contains(string(compose), "phpmyadmin") {
+		t.Errorf("docker-compose.yml includes phpMyAdmin although it was declined:\n%s", compose)
+	}
+
+	if len(runner.Calls) != 1 {
+		t.Fatalf("compose calls = %v, want one up -d", runner.Calls)
+	}
+	if call := runner.Calls[0]; call.Dir != dir || call.String() != "up -d" {
+		t.Errorf("compose call = %q in %s, want \"up -d\" in %s", call.String(), call.Dir, dir)
+	}
+}
@@ -0,0 +1,138 @@
+package autostack
+
+import (
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// memFS is a read-only file system over rendered files
+type memFS struct {
+	files map[string]string   // relative path -> content
+	dirs  map[string][]string // directory -> sorted entry names
+}
+
+// newMemFS builds the file system of files and dirs, creating the parent
+// directories of every entry
+func newMemFS(files map[string]string, dirs []string) *memFS {
+	m := &memFS{files: make(map[string]string), dirs: map[string][]string{".": nil}}
+
+	var add func(p string)
+	add = func(p string) {
+		parent := path.Dir(p)
+		if _, ok := m.dirs[parent]; !ok {
+			m.dirs[parent] = nil
+			add(parent)
+		}
+		for _, name := range m.dirs[parent] {
+			if name == path.Base(p) {
+				return
+			}
+		}
+		m.dirs[parent] = append(m.dirs[parent], path.Base(p))
+	}
+
+	for _, dir := range dirs {
+		dir = path.Clean(dir)
+		if _, ok := m.dirs[dir]; !ok {
+			m.dirs[dir] = nil
+			add(dir)
+		}
+	}
+	for relPath, content := range files {
+		relPath = path.Clean(relPath)
+		m.files[relPath] = content
+		add(relPath)
+	}
+	for dir := range m.dirs {
+		sort.Strings(m.dirs[dir])
+	}
+	return m
+}
+
+// Open implements fs.FS
+func (m *memFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if content, ok := m.files[name]; ok {
+		return &memFile{info: m.info(name), r: strings.NewReader(content)}, nil
+	}
+	if _, ok := m.dirs[name]; ok {
+		return &memDir{fsys: m, name: name}, nil
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// info returns the file info of an existing entry
+func (m *memFS) info(name string) memInfo {
+	if content, ok := m.files[name]; ok {
+		return memInfo{name: path.Base(name), size: int64(len(content))}
+	}
+	return memInfo{name: path.Base(name), dir: true}
+}
+
+// memFile is an open file of a memFS
+type memFile struct {
+	info memInfo
+	r    *strings.Reader
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *memFile) Read(b []byte) (int, error) { return f.r.Read(b) }
+func (f *memFile) Close() error               { return nil }
+
+// memDir is an open directory of a memFS
+type memDir struct {
+	fsys   *memFS
+	name   string
+	offset int
+}
+
+func (d *memDir) Stat() (fs.FileInfo, error) { return d.fsys.info(d.name), nil }
+func (d *memDir) Close() error               { return nil }
+
+func (d *memDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.name, Err: fs.ErrInvalid}
+}
+
+// ReadDir implements fs.ReadDirFile
+func (d *memDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	names := d.fsys.dirs[d.name][d.offset:]
+	if n > 0 && len(names) > n {
+		names = names[:n]
+	}
+	if n > 0 && len(names) == 0 {
+		return nil, io.EOF
+	}
+
+	entries := make([]fs.DirEntry, 0, len(names))
+	for _, name := range names {
+		entries = append(entries, fs.FileInfoToDirEntry(d.fsys.info(path.Join(d.name, name))))
+	}
+	d.offset += len(names)
+	return entries, nil
+}
+
+// memInfo describes an entry of a memFS
+type memInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (i memInfo) Name() string       { return i.name }
+func (i memInfo) Size() int64        { return i.size }
+func (i memInfo) ModTime() time.Time { return time.Time{} }
+func (i memInfo) IsDir() bool        { return i.dir }
+func (i memInfo) Sys() any           { return nil }
+
+func (i memInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0755
+	}
+	return 0644
+}
@@ -0,0 +1,158 @@
+package autostack
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"strings"
+
+	"github.com/bait-py/autostack/internal/stack"
+)
+
+// Options are the values used to render a stack. Variables and ports not
+// given take their default, secrets without a default are generated.
+type Options struct {
+	Env         map[string]string // variable values
+	Ports       map[string]string // service -> host port
+	ProjectName string            // compose project name, defaults to the name of Dir or the stack name
+	Dir         string            // output directory, defaults to the project name or the stack default
+
+	InlineEnv   bool // write values into the files instead of .env
+	SecretFiles bool // write secrets to secrets/ and mount them as compose secrets
+
+	CheckPorts           bool // fail when a host port is in use or claimed by another project
+	AutoPorts            bool // with CheckPorts, pick the next free port instead of failing
+	AllowPrivilegedPorts bool // accept host ports below 1024
+}
+
+// Project is a rendered stack, ready to be inspected or written to disk
+type Project struct {
+	Stack Stack
+	Name  string // compose project name
+	Dir   string // directory Write generates the project into
+
+	Env              map[string]string // value of every enabled variable, secrets included
+	Ports            map[string]string // service -> host port
+	GeneratedSecrets map[string]string // secrets generated at random
+	Files            map[string]string // relative path -> rendered content
+	Dirs             []string          // empty directories to create
+
+	config stack.StackConfig
+}
+
+// Render renders a stack with the given values without touching disk
+func Render(name string, opts Options) (*Project, error) {
+	s, ok := stack.Lookup(name)
+	if !ok {
+		return nil, errors.New("stack not recognized: " + name)
+	}
+
+	stackOpts := stack.Options{
+		Env:             opts.Env,
+		Ports:           opts.Ports,
+		Yes:             true,
+		Name:            opts.ProjectName,
+		Dir:             opts.Dir,
+		InlineEnv:       opts.InlineEnv,
+		SecretFiles:     opts.SecretFiles,
+		SkipPortCheck:   !opts.CheckPorts,
+		AutoPorts:       opts.AutoPorts,
+		AllowPrivileged: opts.AllowPrivilegedPorts,
+	}
+	if err := s.Validate(stackOpts); err != nil {
+		return nil, err
+	}
+
+	config := s.NewConfig(stackOpts)
+	if err := s.Resolve(silentUI(nil), &config, stackOpts); err != nil {
+		return nil, err
+	}
+
+	return &Project{
+		Stack:            newStack(s),
+		Name:             config.ProjectName,
+		Dir:              config.ProjectDir,
+		Env:              config.EnvValues,
+		Ports:            config.PortValues,
+		GeneratedSecrets: config.GeneratedSecrets,
+		Files:            config.Files,
+		Dirs:             config.Dirs,
+		config:           config,
+	}, nil
+}
+
+// FS returns the rendered files and directories as a read-only file system
+func (p *Project) FS() fs.FS {
+	return newMemFS(p.Files, p.Dirs)
+}
+
+// ExistingPolicy decides what Write does with files that already exist
+type ExistingPolicy = stack.ExistingPolicy
+
+const (
+	// RefuseExisting fails without writing anything
+	RefuseExisting = stack.RefuseExisting
+	// OverwriteExisting replaces existing files
+	OverwriteExisting = stack.OverwriteExisting
+	// MergeExisting keeps existing files and only adds missing ones
+	MergeExisting = stack.MergeExisting
+)
+
+// WriteOptions control how a project is written
+type WriteOptions struct {
+	Existing ExistingPolicy
+	Start    bool      // run compose up -d once written
+	Compose  string    // compose implementation used by Start: auto, docker, docker-compose or podman
+	Runner   Runner    // runs compose for Start instead of Compose when set
+	Output   io.Writer // receives progress, the summary, warnings and compose output, discarded when nil
+}
+
+// StartError is returned by Write when the project was written but compose
+// up failed
+type StartError = stack.StartError
+
+// Write generates the project into p.Dir with its lockfile and records it
+// in the per-user project registry. Changes made to p.Files are written.
+// With Start, a failure of compose up is returned as a *StartError.
+func (p *Project) Write(ctx context.Context, opts WriteOptions) error {
+	config := p.config
+	config.ProjectDir = p.Dir
+	if err := stack.CheckProjectName(config.ProjectName, config.ProjectDir); err != nil {
+		return err
+	}
+	config.Dirs = p.Dirs
+	config.Existing = opts.Existing
+	config.Files = make(map[string]string, len(p.Files))
+	for relPath, content := range p.Files {
+		config.Files[relPath] = content
+	}
+
+	// File hashes are recorded on a copy so the project can be written twice
+	lock := *config.Lock
+	lock.Files = make(map[string]string)
+	config.Lock = &lock
+
+	ui := silentUI(opts.Output)
+	if opts.Start {
+		runner := opts.Runner
+		if runner == nil {
+			var err error
+			if runner, err = NewRunner(ctx, opts.Compose, ui); err != nil {
+				return err
+			}
+		}
+		config.Runner = runner
+		config.AutoStart = true
+	}
+
+	return stack.GenerateStack(ctx, ui, config)
+}
+
+// silentUI returns a UI without input that writes to out, or nowhere
+func silentUI(out io.Writer) *stack.UI {
+	if out == nil {
+		out = io.Discard
+	}
+	return stack.NewUI(strings.NewReader(""), out, out)
+}
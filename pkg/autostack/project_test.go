@@ -0,0 +1,30 @@
+package autostack
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteReturnsStartError(t *testing.T) {
+	t.Setenv("AUTOSTACK_STATE_DIR", t.TempDir())
+	dir := filepath.Join(t.TempDir(), "shop")
+
+	p, err := Render("mariadb", Options{Dir: dir})
+	if err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	failure := errors.New("daemon not running")
+	err = p.Write(context.Background(), WriteOptions{Start: true, Runner: &Fake{Err: failure}})
+
+	var startErr *StartError
+	if !errors.As(err, &startErr) || !errors.Is(err, failure) {
+		t.Fatalf("Write = %v, want a StartError wrapping %v", err, failure)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "docker-compose.yml")); err != nil {
+		t.Errorf("the project is not written when compose fails: %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package autostack
+
+import (
+	"github.com/bait-py/autostack/internal/stack"
+)
+
+// ProjectRecord is an entry of the per-user registry of generated projects
+type ProjectRecord = stack.ProjectRecord
+
+// Lock is the content of the lockfile of a generated project
+type Lock = stack.Lock
+
+// Projects returns every project recorded in the registry sorted by name
+func Projects() ([]ProjectRecord, error) {
+	return stack.LoadProjects()
+}
+
+// PruneProjects removes the records of projects whose directory no longer
+// exists and returns them
+func PruneProjects() ([]ProjectRecord, error) {
+	return stack.PruneProjects()
+}
+
+// FindRecord finds a recorded project by name or directory
+func FindRecord(ref string) (ProjectRecord, error) {
+	return stack.FindRecord(ref)
+}
+
+// FindProjectDir locates a generated project by directory or by project
+// name, in the subdirectories of the current directory and in the registry,
+// and returns its directory
+func FindProjectDir(ref string) (string, error) {
+	project, err := stack.FindProject(ref)
+	if err != nil {
+		return "", err
+	}
+	return project.Dir, nil
+}
+
+// ReadLock reads the lockfile of the project generated in dir
+func ReadLock(dir string) (*Lock, error) {
+	return stack.ReadLock(dir)
+}
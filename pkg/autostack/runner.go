@@ -0,0 +1,28 @@
+package autostack
+
+import (
+	"context"
+
+	"github.com/bait-py/autostack/internal/compose"
+)
+
+// Runner runs compose commands in a project directory
+type Runner = compose.Runner
+
+// Fake is a Runner that records calls instead of running anything, for
+// tests and for embedding autostack without Docker
+type Fake = compose.Fake
+
+// Call is a command recorded by Fake
+type Call = compose.Call
+
+// ComposeEnv selects the compose implementation when no name is given
+const ComposeEnv = compose.Env
+
+// NewRunner returns the compose implementation with the given name: auto,
+// docker, docker-compose or podman. An empty name uses AUTOSTACK_COMPOSE
+// and auto detects the implementation. Compose reads from ui.In and writes
+// to ui.Out and ui.Err.
+func NewRunner(ctx context.Context, name string, ui *UI) (Runner, error) {
+	return compose.NewWithStreams(ctx, name, ui.In, ui.Out, ui.Err)
+}